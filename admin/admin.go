@@ -0,0 +1,378 @@
+// Package admin exposes an HTTP API for inspecting and operating a live cache.
+package admin
+
+import (
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"io"
+	lcache "lcache"
+	"lcache/auth"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// routes, relative to where the handler is mounted:
+//
+//	GET    /                  HTML dashboard
+//	GET    /healthz           liveness probe, fails once the cache is closed
+//	GET    /readyz            readiness probe, see lcache.Cache.Ready
+//	GET    /stats             cache statistics
+//	GET    /report            plain text diagnostic report, see lcache.Cache.Report
+//	GET    /resources         goroutines, tickers and open files by owner
+//	GET    /sample?n=&by=     metadata of n random entries (default 100), add
+//	                          by=size to weight the pick by value size
+//	GET    /topkeys?n=        most read keys, needs CacheOptions.TrackTopKeys
+//	GET    /keys?prefix=&cursor=&limit=
+//	                          page of entry metadata without values, pass the
+//	                          returned cursor back until it is "0"
+//	HEAD   /keys/{key}        200 if the key is cached, 404 otherwise
+//	GET    /keys/{key}        entry metadata, add ?value=true to include the value
+//	PUT    /keys/{key}?ttl=   store the request body, ttl in time.ParseDuration syntax
+//	DELETE /keys/{key}?delay= delete an entry, with delay (a duration) also
+//	                          refuse writes to it for that long
+//	POST   /flush             clear the cache, or with ?pattern= (a glob) or
+//	                          ?prefix= only the matching keys; add
+//	                          &dry_run=true to count the matches first
+//	POST   /resize?max_bytes= change MaxBytes
+//	POST   /snapshot          write a snapshot to SnapshotPath
+//	POST   /maintenance/pause pause expiry and eviction, see PauseMaintenance
+//	POST   /maintenance/resume
+//	                          resume them
+
+//go:embed dashboard.html
+var dashboardHTML []byte
+
+type Handler struct {
+	cache *lcache.Cache
+	auth  *auth.Authenticator
+}
+
+// NewHandler returns the admin API for c; mount it with http.StripPrefix
+func NewHandler(c *lcache.Cache) *Handler {
+	return &Handler{cache: c}
+}
+
+// WithAuth requires credentials from a: read access for GET, write access for
+// everything else. The health probes stay open.
+func (h *Handler) WithAuth(a *auth.Authenticator) *Handler {
+	h.auth = a
+	return h
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	path := "/" + strings.TrimPrefix(r.URL.Path, "/")
+	if path != "/healthz" && path != "/readyz" && !h.auth.Check(w, r, auth.MethodRole(r)) {
+		return
+	}
+	switch {
+	case path == "/" || path == "/dashboard":
+		h.only(w, r, http.MethodGet, h.dashboard)
+	case path == "/topkeys":
+		h.only(w, r, http.MethodGet, h.topKeys)
+	case path == "/healthz":
+		h.only(w, r, http.MethodGet, probe(h.cache.Live))
+	case path == "/readyz":
+		h.only(w, r, http.MethodGet, probe(h.cache.Ready))
+	case path == "/stats":
+		h.only(w, r, http.MethodGet, h.stats)
+	case path == "/sample":
+		h.only(w, r, http.MethodGet, h.sample)
+	case path == "/report":
+		h.only(w, r, http.MethodGet, h.report)
+	case path == "/resources":
+		h.only(w, r, http.MethodGet, h.resources)
+	case path == "/keys":
+		h.only(w, r, http.MethodGet, h.listKeys)
+	case strings.HasPrefix(path, "/keys/") && len(path) > len("/keys/"):
+		key := strings.TrimPrefix(path, "/keys/")
+		switch r.Method {
+		case http.MethodHead:
+			if h.cache.Has(key) {
+				w.WriteHeader(http.StatusOK)
+			} else {
+				w.WriteHeader(http.StatusNotFound)
+			}
+		case http.MethodGet:
+			h.getKey(w, r, key)
+		case http.MethodPut:
+			h.putKey(w, r, key)
+		case http.MethodDelete:
+			h.deleteKey(w, r, key)
+		default:
+			writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		}
+	case path == "/flush":
+		h.only(w, r, http.MethodPost, h.flush)
+	case path == "/resize":
+		h.only(w, r, http.MethodPost, h.resize)
+	case path == "/snapshot":
+		h.only(w, r, http.MethodPost, h.snapshot)
+	case path == "/maintenance/pause":
+		h.only(w, r, http.MethodPost, h.maintenance(h.cache.PauseMaintenance))
+	case path == "/maintenance/resume":
+		h.only(w, r, http.MethodPost, h.maintenance(h.cache.ResumeMaintenance))
+	default:
+		writeError(w, http.StatusNotFound, "not found")
+	}
+}
+
+func (h *Handler) only(w http.ResponseWriter, r *http.Request, method string, fn func(http.ResponseWriter, *http.Request)) {
+	if r.Method != method {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	fn(w, r)
+}
+
+func (h *Handler) report(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	io.WriteString(w, h.cache.Report())
+}
+
+func (h *Handler) dashboard(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write(dashboardHTML)
+}
+
+func (h *Handler) topKeys(w http.ResponseWriter, r *http.Request) {
+	n := 20
+	if v := r.URL.Query().Get("n"); v != "" {
+		var err error
+		if n, err = strconv.Atoi(v); err != nil || n < 0 {
+			writeError(w, http.StatusBadRequest, "n must be a non-negative integer")
+			return
+		}
+	}
+	writeJSON(w, http.StatusOK, h.cache.TopKeys(n))
+}
+
+// HealthzHandler and ReadyzHandler serve the probes on their own, for mounting
+// at the paths Kubernetes expects without exposing the rest of the admin API
+func HealthzHandler(c *lcache.Cache) http.Handler {
+	return probe(c.Live)
+}
+
+func ReadyzHandler(c *lcache.Cache) http.Handler {
+	return probe(c.Ready)
+}
+
+// probe answers 200 when check passes and 503 with the reason otherwise
+func probe(check func() error) http.HandlerFunc {
+	return func(w http.ResponseWriter, _ *http.Request) {
+		if err := check(); err != nil {
+			writeError(w, http.StatusServiceUnavailable, err.Error())
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+	}
+}
+
+func (h *Handler) stats(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, h.cache.Stats())
+}
+
+func (h *Handler) resources(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, h.cache.Resources())
+}
+
+func (h *Handler) sample(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	n := 100
+	if v := query.Get("n"); v != "" {
+		var err error
+		if n, err = strconv.Atoi(v); err != nil || n < 0 || n > maxKeysLimit {
+			writeError(w, http.StatusBadRequest, "n must be between 0 and "+strconv.Itoa(maxKeysLimit))
+			return
+		}
+	}
+	switch query.Get("by") {
+	case "":
+		writeJSON(w, http.StatusOK, h.cache.Sample(n))
+	case "size":
+		writeJSON(w, http.StatusOK, h.cache.SampleBySize(n))
+	default:
+		writeError(w, http.StatusBadRequest, "by must be empty or \"size\"")
+	}
+}
+
+// maxKeysLimit bounds the page size of GET /keys
+const maxKeysLimit = 1000
+
+type keysResponse struct {
+	Keys   []lcache.EntryInfo `json:"keys"`
+	Cursor string             `json:"cursor"` // a string, cursors don't fit in a JSON number
+}
+
+func (h *Handler) listKeys(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	var cursor uint64
+	if v := query.Get("cursor"); v != "" {
+		var err error
+		if cursor, err = strconv.ParseUint(v, 10, 64); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid cursor")
+			return
+		}
+	}
+	limit := 100
+	if v := query.Get("limit"); v != "" {
+		var err error
+		if limit, err = strconv.Atoi(v); err != nil || limit <= 0 || limit > maxKeysLimit {
+			writeError(w, http.StatusBadRequest, "limit must be between 1 and "+strconv.Itoa(maxKeysLimit))
+			return
+		}
+	}
+
+	keys, next := h.cache.Scan(cursor, query.Get("prefix"), limit)
+	writeJSON(w, http.StatusOK, keysResponse{Keys: keys, Cursor: strconv.FormatUint(next, 10)})
+}
+
+type keyResponse struct {
+	lcache.EntryInfo
+	TTLSeconds float64 `json:"ttl_seconds,omitempty"`
+	Value      []byte  `json:"value,omitempty"`
+}
+
+func (h *Handler) getKey(w http.ResponseWriter, r *http.Request, key string) {
+	info, value, ok := h.cache.Inspect(key)
+	if !ok {
+		writeError(w, http.StatusNotFound, "key not found")
+		return
+	}
+	resp := keyResponse{EntryInfo: info, TTLSeconds: info.TTL().Seconds()}
+	if withValue, _ := strconv.ParseBool(r.URL.Query().Get("value")); withValue {
+		resp.Value = value.ByteSlice()
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// maxValueBytes bounds request bodies accepted by PUT
+const maxValueBytes = 64 << 20
+
+func (h *Handler) putKey(w http.ResponseWriter, r *http.Request, key string) {
+	var ttl time.Duration
+	if v := r.URL.Query().Get("ttl"); v != "" {
+		var err error
+		if ttl, err = time.ParseDuration(v); err != nil || ttl < 0 {
+			writeError(w, http.StatusBadRequest, "ttl must be a non-negative duration")
+			return
+		}
+	}
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxValueBytes))
+	if err != nil {
+		writeError(w, http.StatusRequestEntityTooLarge, err.Error())
+		return
+	}
+	switch err := h.cache.SetCtx(r.Context(), key, lcache.NewByteView(body), ttl); {
+	case err == nil:
+		writeJSON(w, http.StatusOK, map[string]interface{}{"stored": key, "size": len(body)})
+	case errors.Is(err, lcache.ErrValueTooLarge), errors.Is(err, lcache.ErrQuotaExceeded):
+		writeError(w, http.StatusRequestEntityTooLarge, err.Error())
+	case errors.Is(err, lcache.ErrTombstoned):
+		writeError(w, http.StatusConflict, err.Error())
+	default:
+		writeError(w, http.StatusServiceUnavailable, err.Error())
+	}
+}
+
+func (h *Handler) deleteKey(w http.ResponseWriter, r *http.Request, key string) {
+	var delay time.Duration
+	if v := r.URL.Query().Get("delay"); v != "" {
+		var err error
+		if delay, err = time.ParseDuration(v); err != nil || delay < 0 {
+			writeError(w, http.StatusBadRequest, "delay must be a non-negative duration")
+			return
+		}
+	}
+	switch err := h.cache.DeleteWithDelay(key, delay); {
+	case err == nil:
+		writeJSON(w, http.StatusOK, map[string]interface{}{"deleted": key})
+	case errors.Is(err, lcache.ErrKeyNotFound):
+		writeError(w, http.StatusNotFound, err.Error())
+	default:
+		writeError(w, http.StatusServiceUnavailable, err.Error())
+	}
+}
+
+func (h *Handler) flush(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	pattern := query.Get("pattern")
+	if prefix := query.Get("prefix"); prefix != "" {
+		if pattern != "" {
+			writeError(w, http.StatusBadRequest, "pattern and prefix are exclusive")
+			return
+		}
+		pattern = escapePattern(prefix) + "*"
+	}
+	if pattern != "" {
+		dryRun, _ := strconv.ParseBool(query.Get("dry_run"))
+		n := h.cache.FlushPattern(pattern, dryRun)
+		if dryRun {
+			writeJSON(w, http.StatusOK, map[string]interface{}{"pattern": pattern, "matched": n, "dry_run": true})
+		} else {
+			writeJSON(w, http.StatusOK, map[string]interface{}{"pattern": pattern, "deleted": n})
+		}
+		return
+	}
+	h.cache.Clear()
+	writeJSON(w, http.StatusOK, map[string]interface{}{"flushed": true})
+}
+
+// escapePattern quotes the glob metacharacters in a literal prefix
+func escapePattern(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if strings.ContainsRune(`*?[]\`, r) {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+func (h *Handler) resize(w http.ResponseWriter, r *http.Request) {
+	maxBytes, err := strconv.ParseInt(r.URL.Query().Get("max_bytes"), 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "max_bytes must be an integer")
+		return
+	}
+	if err := h.cache.Resize(maxBytes); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"max_bytes": maxBytes})
+}
+
+func (h *Handler) snapshot(w http.ResponseWriter, _ *http.Request) {
+	if err := h.cache.WriteSnapshot(); err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"snapshot": true})
+}
+
+func (h *Handler) maintenance(fn func() error) func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, _ *http.Request) {
+		if err := fn(); err != nil {
+			status := http.StatusInternalServerError
+			if errors.Is(err, lcache.ErrNotSupported) {
+				status = http.StatusNotImplemented
+			}
+			writeError(w, status, err.Error())
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{"paused": h.cache.MaintenancePaused()})
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, msg string) {
+	writeJSON(w, status, map[string]string{"error": msg})
+}
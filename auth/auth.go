@@ -0,0 +1,148 @@
+// Package auth checks credentials for the admin, REST and RESP endpoints and
+// maps them to read-only or read-write roles.
+package auth
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+type Role int
+
+const (
+	RoleNone Role = iota
+	RoleRead
+	RoleWrite // implies RoleRead
+)
+
+func (r Role) String() string {
+	switch r {
+	case RoleRead:
+		return "read"
+	case RoleWrite:
+		return "write"
+	default:
+		return "none"
+	}
+}
+
+// Allows reports whether r grants need
+func (r Role) Allows(need Role) bool {
+	return r >= need
+}
+
+type credential struct {
+	user   string
+	secret [sha256.Size]byte
+	role   Role
+}
+
+// Authenticator holds bearer tokens and basic-auth users. A nil Authenticator
+// grants RoleWrite to everyone, which keeps endpoints open unless auth is set up.
+
+type Authenticator struct {
+	mu     sync.RWMutex
+	tokens []credential
+	users  []credential
+}
+
+func New() *Authenticator {
+	return &Authenticator{}
+}
+
+// AddToken accepts token as a bearer token (or a password without user in RESP)
+func (a *Authenticator) AddToken(token string, role Role) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.tokens = append(a.tokens, credential{secret: sha256.Sum256([]byte(token)), role: role})
+}
+
+// AddUser accepts user and password through basic auth or RESP AUTH
+func (a *Authenticator) AddUser(user, password string, role Role) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.users = append(a.users, credential{user: user, secret: sha256.Sum256([]byte(password)), role: role})
+}
+
+// Token returns the role granted to token, comparing in constant time
+func (a *Authenticator) Token(token string) Role {
+	if a == nil {
+		return RoleWrite
+	}
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return match(a.tokens, "", token)
+}
+
+// User returns the role granted to user and password
+func (a *Authenticator) User(user, password string) Role {
+	if a == nil {
+		return RoleWrite
+	}
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return match(a.users, user, password)
+}
+
+func match(creds []credential, user, secret string) Role {
+	sum := sha256.Sum256([]byte(secret))
+	role := RoleNone
+	for _, c := range creds {
+		if subtle.ConstantTimeCompare(c.secret[:], sum[:]) == 1 && c.user == user && c.role > role {
+			role = c.role
+		}
+	}
+	return role
+}
+
+// Request returns the role granted by a request's bearer token or basic auth
+func (a *Authenticator) Request(r *http.Request) Role {
+	if a == nil {
+		return RoleWrite
+	}
+	if user, password, ok := r.BasicAuth(); ok {
+		return a.User(user, password)
+	}
+	if h := r.Header.Get("Authorization"); strings.HasPrefix(h, "Bearer ") {
+		return a.Token(strings.TrimPrefix(h, "Bearer "))
+	}
+	return RoleNone
+}
+
+// MethodRole is the role an HTTP request needs: read for GET and HEAD, write otherwise
+func MethodRole(r *http.Request) Role {
+	if r.Method == http.MethodGet || r.Method == http.MethodHead || r.Method == http.MethodOptions {
+		return RoleRead
+	}
+	return RoleWrite
+}
+
+// Check writes 401 or 403 and returns false when r doesn't carry the role it needs
+func (a *Authenticator) Check(w http.ResponseWriter, r *http.Request, need Role) bool {
+	role := a.Request(r)
+	if role.Allows(need) {
+		return true
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if role == RoleNone {
+		w.Header().Set("WWW-Authenticate", `Basic realm="lcache"`)
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(`{"error":"authentication required"}` + "\n"))
+		return false
+	}
+	w.WriteHeader(http.StatusForbidden)
+	w.Write([]byte(`{"error":"` + need.String() + ` access required"}` + "\n"))
+	return false
+}
+
+// Middleware guards next, requiring MethodRole for every request
+func (a *Authenticator) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if a.Check(w, r, MethodRole(r)) {
+			next.ServeHTTP(w, r)
+		}
+	})
+}
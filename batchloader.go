@@ -0,0 +1,147 @@
+package LCache_go
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// BatchLoaderFunc fetches several missing keys in one backend call, e.g. a single
+// SQL IN (...) query. Keys left out of the result are misses; a returned error
+// fails every key in the batch.
+type BatchLoaderFunc func(ctx context.Context, keys []string) (map[string]LoadResult, error)
+
+// LoadResult is one key loaded by a BatchLoaderFunc, a zero TTL falls back to DefaultTTL
+type LoadResult struct {
+	Value ByteView
+	TTL   time.Duration
+}
+
+const defaultBatchWindow = 2 * time.Millisecond
+
+// batcher collects keys missed within a window and loads them with one call
+
+type batcher struct {
+	fn      BatchLoaderFunc
+	window  time.Duration
+	max     int
+	timeout time.Duration
+
+	mu      sync.Mutex
+	keys    []string
+	waiters []chan batchResult
+	timer   *time.Timer
+	batches int64
+	res     *resourceTracker
+}
+
+type batchResult struct {
+	val ByteView
+	ttl time.Duration
+	err error
+}
+
+func newBatcher(opts CacheOptions) *batcher {
+	b := &batcher{
+		fn:      opts.BatchLoader,
+		window:  opts.BatchWindow,
+		max:     opts.MaxBatchSize,
+		timeout: opts.LoaderTimeout,
+	}
+	if b.window == 0 {
+		b.window = defaultBatchWindow
+	}
+	return b
+}
+
+// load queues key for the next batch and waits for its result. Each key is
+// queued at most once at a time because loads already go through the flightGroup.
+func (b *batcher) load(ctx context.Context, key string) (ByteView, time.Duration, error) {
+	ch := make(chan batchResult, 1)
+
+	b.mu.Lock()
+	b.keys = append(b.keys, key)
+	b.waiters = append(b.waiters, ch)
+	if b.max > 0 && len(b.keys) >= b.max {
+		keys, waiters := b.take()
+		b.mu.Unlock()
+		go b.run(keys, waiters)
+	} else {
+		if len(b.keys) == 1 {
+			b.timer = time.AfterFunc(b.window, b.flush)
+		}
+		b.mu.Unlock()
+	}
+
+	select {
+	case res := <-ch:
+		return res.val, res.ttl, res.err
+	case <-ctx.Done():
+		return ByteView{}, 0, ctx.Err()
+	}
+}
+
+// take detaches the pending batch, need to hold the lock
+func (b *batcher) take() ([]string, []chan batchResult) {
+	keys, waiters := b.keys, b.waiters
+	b.keys, b.waiters = nil, nil
+	if b.timer != nil {
+		b.timer.Stop()
+		b.timer = nil
+	}
+	return keys, waiters
+}
+
+func (b *batcher) flush() {
+	b.mu.Lock()
+	keys, waiters := b.take()
+	b.mu.Unlock()
+	if len(keys) > 0 {
+		b.run(keys, waiters)
+	}
+}
+
+func (b *batcher) run(keys []string, waiters []chan batchResult) {
+	defer b.res.acquire(resGoroutine, "batch_loader")()
+	atomic.AddInt64(&b.batches, 1)
+	ctx := context.Background()
+	if b.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, b.timeout)
+		defer cancel()
+	}
+
+	results, err := b.fn(ctx, keys)
+	for i, key := range keys {
+		var res batchResult
+		if err != nil {
+			res.err = err
+		} else if r, ok := results[key]; ok {
+			res.val, res.ttl = r.Value, r.TTL
+		} else {
+			res.err = ErrKeyNotFound
+		}
+		waiters[i] <- res
+	}
+}
+
+// fetch runs whichever loader is configured for a single key
+func (c *Cache) fetch(ctx context.Context, key string) (ByteView, time.Duration, error) {
+	if c.loaderFaults != nil {
+		if err := c.loaderFaults.inject(ctx); err != nil {
+			return ByteView{}, 0, err
+		}
+	}
+	if c.batcher != nil {
+		return c.batcher.load(ctx, key)
+	}
+	if c.fallbacks != nil {
+		return c.fallbacks.load(ctx, key)
+	}
+	return c.opts.Loader(ctx, key)
+}
+
+func (c *Cache) hasLoader() bool {
+	return c.opts.Loader != nil || c.batcher != nil || c.fallbacks != nil
+}
@@ -0,0 +1,239 @@
+// Package bench measures cache throughput and latency under generated
+// workloads: Zipfian or uniform key popularity, a read/write mix and a value
+// size distribution. Workloads are seeded, so a report can be reproduced by
+// rerunning the same Workload against the same targets.
+package bench
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	lcache "lcache"
+	"lcache/store"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"text/tabwriter"
+	"time"
+)
+
+// Client is what a benchmark drives
+
+type Client interface {
+	Get(key string) bool
+	Set(key string, value []byte) error
+	Close()
+}
+
+// Target is a named way to open a fresh Client, one per run
+type Target struct {
+	Name string
+	Open func() (Client, error)
+}
+
+// StoreTarget benchmarks a bare store of cacheType
+func StoreTarget(cacheType store.CacheType, opts store.Options) Target {
+	return Target{
+		Name: "store/" + string(cacheType),
+		Open: func() (Client, error) {
+			s, err := store.NewStore(cacheType, opts)
+			if err != nil {
+				return nil, err
+			}
+			return storeClient{s}, nil
+		},
+	}
+}
+
+// CacheTarget benchmarks a full cache, including its stats, key and value
+// pipeline, named after opts.Name
+func CacheTarget(opts lcache.CacheOptions) Target {
+	return Target{
+		Name: "cache/" + opts.Name,
+		Open: func() (Client, error) {
+			c, err := lcache.NewCache(opts)
+			if err != nil {
+				return nil, err
+			}
+			return cacheClient{c}, nil
+		},
+	}
+}
+
+// value hands a generated buffer to a store without copying it
+type value []byte
+
+func (v value) Len() int { return len(v) }
+
+type storeClient struct{ s store.Store }
+
+func (c storeClient) Get(key string) bool {
+	_, ok := c.s.Get(key)
+	return ok
+}
+
+func (c storeClient) Set(key string, v []byte) error { return c.s.Set(key, value(v)) }
+func (c storeClient) Close()                         { c.s.Close() }
+
+type cacheClient struct{ c *lcache.Cache }
+
+func (c cacheClient) Get(key string) bool {
+	_, err := c.c.Lookup(key)
+	return err == nil
+}
+
+func (c cacheClient) Set(key string, v []byte) error { return c.c.Set(key, lcache.NewByteView(v)) }
+func (c cacheClient) Close()                         { c.c.Close() }
+
+// Report is the outcome of one workload against one target
+
+type Report struct {
+	Target     string
+	Workload   Workload
+	Elapsed    time.Duration
+	Gets       int64
+	Hits       int64
+	Sets       int64
+	SetErrors  int64
+	GetLatency lcache.LatencyStats
+	SetLatency lcache.LatencyStats
+}
+
+// Throughput is operations per second
+func (r Report) Throughput() float64 {
+	if r.Elapsed <= 0 {
+		return 0
+	}
+	return float64(r.Gets+r.Sets) / r.Elapsed.Seconds()
+}
+
+// HitRate is the fraction of reads that found their key
+func (r Report) HitRate() float64 {
+	if r.Gets == 0 {
+		return 0
+	}
+	return float64(r.Hits) / float64(r.Gets)
+}
+
+// Run drives w against every target in turn, each with a fresh client and the
+// same sequence of operations
+func Run(w Workload, targets ...Target) ([]Report, error) {
+	if err := w.validate(); err != nil {
+		return nil, err
+	}
+	if len(targets) == 0 {
+		return nil, errors.New("bench: no targets")
+	}
+	w = w.withDefaults()
+	keys := make([]string, w.Keys)
+	for i := range keys {
+		keys[i] = "key:" + strconv.Itoa(i)
+	}
+	reports := make([]Report, 0, len(targets))
+	for _, t := range targets {
+		r, err := run(w, keys, t)
+		if err != nil {
+			return reports, fmt.Errorf("bench: %s: %w", t.Name, err)
+		}
+		reports = append(reports, r)
+	}
+	return reports, nil
+}
+
+func run(w Workload, keys []string, t Target) (Report, error) {
+	c, err := t.Open()
+	if err != nil {
+		return Report{}, err
+	}
+	defer c.Close()
+	if w.Prefill {
+		g := newGenerator(w, keys, w.Seed)
+		for _, k := range keys {
+			c.Set(k, g.nextValue())
+		}
+	}
+
+	var (
+		gets, hits, sets, setErrors int64
+		getLat, setLat              lcache.Histogram
+		wg                          sync.WaitGroup
+	)
+	start := time.Now()
+	for i := 0; i < w.Workers; i++ {
+		ops := w.Ops / w.Workers
+		if i < w.Ops%w.Workers {
+			ops++
+		}
+		g := newGenerator(w, keys, w.Seed+int64(i)+1)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var n, h, s, e int64
+			for j := 0; j < ops; j++ {
+				key, read := g.next()
+				if read {
+					opStart := time.Now()
+					ok := c.Get(key)
+					getLat.Record(int64(time.Since(opStart)))
+					n++
+					if ok {
+						h++
+					}
+					continue
+				}
+				v := g.nextValue()
+				opStart := time.Now()
+				err := c.Set(key, v)
+				setLat.Record(int64(time.Since(opStart)))
+				s++
+				if err != nil {
+					e++
+				}
+			}
+			atomic.AddInt64(&gets, n)
+			atomic.AddInt64(&hits, h)
+			atomic.AddInt64(&sets, s)
+			atomic.AddInt64(&setErrors, e)
+		}()
+	}
+	wg.Wait()
+	return Report{
+		Target:     t.Name,
+		Workload:   w,
+		Elapsed:    time.Since(start),
+		Gets:       gets,
+		Hits:       hits,
+		Sets:       sets,
+		SetErrors:  setErrors,
+		GetLatency: latencyStats(&getLat),
+		SetLatency: latencyStats(&setLat),
+	}, nil
+}
+
+func latencyStats(h *lcache.Histogram) lcache.LatencyStats {
+	return lcache.LatencyStats{
+		Count: h.Count(),
+		P50:   time.Duration(h.P50()),
+		P95:   time.Duration(h.P95()),
+		P99:   time.Duration(h.P99()),
+		Max:   time.Duration(h.Max()),
+	}
+}
+
+// WriteTable writes reports as an aligned table under a line describing the
+// workload of the first one
+func WriteTable(w io.Writer, reports []Report) error {
+	if len(reports) == 0 {
+		return nil
+	}
+	if _, err := fmt.Fprintf(w, "workload: %v seed=%d\n\n", reports[0].Workload, reports[0].Workload.Seed); err != nil {
+		return err
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "target\tops/s\thit rate\tget p50\tget p99\tset p50\tset p99\tset errors\t")
+	for _, r := range reports {
+		fmt.Fprintf(tw, "%s\t%.0f\t%.3f\t%v\t%v\t%v\t%v\t%d\t\n", r.Target, r.Throughput(), r.HitRate(),
+			r.GetLatency.P50, r.GetLatency.P99, r.SetLatency.P50, r.SetLatency.P99, r.SetErrors)
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,154 @@
+package bench
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"strconv"
+)
+
+// Workload describes the traffic a benchmark generates. Zero fields take the
+// defaults noted.
+
+type Workload struct {
+	Keys      int      // Distinct keys, defaults to 100000
+	Zipf      float64  // Skew of key popularity, must be > 1 when set; 0 picks keys uniformly
+	ReadRatio float64  // Fraction of operations that are reads, defaults to 0.9; use a negative value for write-only
+	ValueSize SizeDist // Sizes of written values, defaults to Fixed(128)
+	Ops       int      // Operations per target, defaults to 1000000
+	Workers   int      // Goroutines issuing operations, defaults to 1
+	Prefill   bool     // Write every key once before measuring
+	Seed      int64    // Seeds the generators, so runs are reproducible
+}
+
+func (w Workload) withDefaults() Workload {
+	if w.Keys == 0 {
+		w.Keys = 100000
+	}
+	if w.ReadRatio == 0 {
+		w.ReadRatio = 0.9
+	}
+	if w.ValueSize == nil {
+		w.ValueSize = Fixed(128)
+	}
+	if w.Ops == 0 {
+		w.Ops = 1000000
+	}
+	if w.Workers == 0 {
+		w.Workers = 1
+	}
+	return w
+}
+
+func (w Workload) validate() error {
+	if w.Keys < 0 || w.Ops < 0 || w.Workers < 0 {
+		return fmt.Errorf("bench: Keys, Ops and Workers must not be negative")
+	}
+	if w.Zipf != 0 && w.Zipf <= 1 {
+		return fmt.Errorf("bench: Zipf must be greater than 1, got %v", w.Zipf)
+	}
+	if w.ReadRatio > 1 {
+		return fmt.Errorf("bench: ReadRatio must be at most 1, got %v", w.ReadRatio)
+	}
+	return nil
+}
+
+// String describes w for report headers
+func (w Workload) String() string {
+	dist := "uniform"
+	if w.Zipf != 0 {
+		dist = "zipf(" + strconv.FormatFloat(w.Zipf, 'g', -1, 64) + ")"
+	}
+	reads := w.ReadRatio
+	if reads < 0 {
+		reads = 0
+	}
+	return fmt.Sprintf("keys=%d dist=%s reads=%.0f%% values=%v ops=%d workers=%d",
+		w.Keys, dist, reads*100, w.ValueSize, w.Ops, w.Workers)
+}
+
+// generator yields the operations of one worker
+type generator struct {
+	w     Workload
+	keys  []string
+	rnd   *rand.Rand
+	zipf  *rand.Zipf
+	value []byte
+}
+
+func newGenerator(w Workload, keys []string, seed int64) *generator {
+	g := &generator{w: w, keys: keys, rnd: rand.New(rand.NewSource(seed))}
+	if w.Zipf != 0 {
+		g.zipf = rand.NewZipf(g.rnd, w.Zipf, 1, uint64(len(keys)-1))
+	}
+	return g
+}
+
+// next returns the key of the next operation and whether it is a read
+func (g *generator) next() (key string, read bool) {
+	var i int
+	if g.zipf != nil {
+		i = int(g.zipf.Uint64())
+	} else {
+		i = g.rnd.Intn(len(g.keys))
+	}
+	return g.keys[i], g.rnd.Float64() < g.w.ReadRatio
+}
+
+// nextValue returns a value of the next size, reusing one buffer
+func (g *generator) nextValue() []byte {
+	n := g.w.ValueSize.Size(g.rnd)
+	if cap(g.value) < n {
+		g.value = make([]byte, n)
+		g.rnd.Read(g.value)
+	}
+	return g.value[:n]
+}
+
+// SizeDist draws value sizes
+
+type SizeDist interface {
+	Size(r *rand.Rand) int
+}
+
+// Fixed always returns n bytes
+type Fixed int
+
+func (f Fixed) Size(*rand.Rand) int { return int(f) }
+func (f Fixed) String() string      { return strconv.Itoa(int(f)) }
+
+// Uniform draws sizes evenly from [Min, Max]
+type Uniform struct {
+	Min, Max int
+}
+
+func (u Uniform) Size(r *rand.Rand) int {
+	if u.Max <= u.Min {
+		return u.Min
+	}
+	return u.Min + r.Intn(u.Max-u.Min+1)
+}
+
+func (u Uniform) String() string { return fmt.Sprintf("uniform(%d-%d)", u.Min, u.Max) }
+
+// Pareto draws heavy-tailed sizes of at least Min with shape Alpha, capped at
+// Max, the mix of many small values and a few large ones typical of caches
+type Pareto struct {
+	Min, Max int
+	Alpha    float64
+}
+
+func (p Pareto) Size(r *rand.Rand) int {
+	alpha := p.Alpha
+	if alpha <= 0 {
+		alpha = 1.5
+	}
+	// inverse transform sampling, 1-Float64 avoids dividing by zero
+	n := float64(p.Min) / math.Pow(1-r.Float64(), 1/alpha)
+	if p.Max > 0 && n > float64(p.Max) {
+		return p.Max
+	}
+	return int(n)
+}
+
+func (p Pareto) String() string { return fmt.Sprintf("pareto(%d-%d, %g)", p.Min, p.Max, p.Alpha) }
@@ -0,0 +1,169 @@
+package LCache_go
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math"
+	"sync"
+	"time"
+)
+
+// AbsentFilterOptions configure the filter of keys the loader reported absent,
+// see CacheOptions.AbsentFilter. Zero fields take the defaults noted.
+
+type AbsentFilterOptions struct {
+	ExpectedKeys      int           // Absent keys per rotation the filter is sized for, defaults to 100000
+	FalsePositiveRate float64       // Target false positive rate at ExpectedKeys, defaults to 0.01
+	Rotate            time.Duration // How long an absent key is remembered, between one and two of these; defaults to one minute
+}
+
+func (o AbsentFilterOptions) validate() error {
+	if o.ExpectedKeys < 0 || o.Rotate < 0 {
+		return fmt.Errorf("lcache: AbsentFilter settings must not be negative")
+	}
+	if o.FalsePositiveRate < 0 || o.FalsePositiveRate >= 1 {
+		return fmt.Errorf("lcache: AbsentFilter FalsePositiveRate must be in [0, 1), got %v", o.FalsePositiveRate)
+	}
+	return nil
+}
+
+// absentFilter remembers keys the loader missed so later misses skip the
+// loader. Bloom filters can't forget single keys, so it keeps two and rotates
+// them: a key is remembered while it is in the current or previous filter,
+// which also stops false positives from piling up.
+
+type absentFilter struct {
+	opts AbsentFilterOptions
+
+	mu      sync.Mutex
+	cur     *bloomFilter
+	prev    *bloomFilter
+	rotated time.Time
+
+	hits int64 // misses answered without the loader
+	adds int64
+}
+
+func newAbsentFilter(opts AbsentFilterOptions) *absentFilter {
+	if opts.ExpectedKeys == 0 {
+		opts.ExpectedKeys = 100000
+	}
+	if opts.FalsePositiveRate == 0 {
+		opts.FalsePositiveRate = 0.01
+	}
+	if opts.Rotate == 0 {
+		opts.Rotate = time.Minute
+	}
+	return &absentFilter{
+		opts:    opts,
+		cur:     newBloomFilter(opts.ExpectedKeys, opts.FalsePositiveRate),
+		rotated: time.Now(),
+	}
+}
+
+// rotateIfDue starts a fresh filter once Rotate has passed, need to hold the lock
+func (f *absentFilter) rotateIfDue(now time.Time) {
+	if now.Sub(f.rotated) < f.opts.Rotate {
+		return
+	}
+	f.prev = f.cur
+	if now.Sub(f.rotated) >= 2*f.opts.Rotate {
+		// idle for a whole period, the previous filter is stale too
+		f.prev = nil
+	}
+	f.cur = newBloomFilter(f.opts.ExpectedKeys, f.opts.FalsePositiveRate)
+	f.rotated = now
+}
+
+func (f *absentFilter) add(key string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.rotateIfDue(time.Now())
+	f.cur.add(key)
+	f.adds++
+}
+
+// absent reports whether key was probably reported absent recently
+func (f *absentFilter) absent(key string) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.rotateIfDue(time.Now())
+	if f.cur.mayContain(key) || (f.prev != nil && f.prev.mayContain(key)) {
+		f.hits++
+		return true
+	}
+	return false
+}
+
+// reset forgets every key, e.g. when the backing store changed wholesale
+func (f *absentFilter) reset() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.cur = newBloomFilter(f.opts.ExpectedKeys, f.opts.FalsePositiveRate)
+	f.prev = nil
+	f.rotated = time.Now()
+}
+
+func (f *absentFilter) stats(stats Stats) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	stats["absent_filter_hits"] = f.hits
+	stats["absent_filter_adds"] = f.adds
+}
+
+// ForgetAbsent clears the filter of absent keys, e.g. after a bulk import into
+// the backing store. It does nothing without CacheOptions.AbsentFilter.
+func (c *Cache) ForgetAbsent() {
+	if c.absent != nil {
+		c.absent.reset()
+	}
+}
+
+// bloomFilter is a plain bloom filter using double hashing
+
+type bloomFilter struct {
+	bits []uint64
+	k    uint64
+}
+
+func newBloomFilter(n int, p float64) *bloomFilter {
+	m := math.Ceil(-float64(n) * math.Log(p) / (math.Ln2 * math.Ln2))
+	k := math.Round(m / float64(n) * math.Ln2)
+	if k < 1 {
+		k = 1
+	}
+	words := (uint64(m) + 63) / 64
+	return &bloomFilter{bits: make([]uint64, words), k: uint64(k)}
+}
+
+func (b *bloomFilter) locations(key string) (h1, h2, m uint64) {
+	h := fnv.New64a()
+	h.Write([]byte(key))
+	h1 = h.Sum64()
+	// fmix64 from murmur3, fnv alone leaves the high bits poorly mixed
+	h2 = h1 ^ h1>>33
+	h2 *= 0xff51afd7ed558ccd
+	h2 ^= h2 >> 33
+	h2 *= 0xc4ceb9fe1a85ec53
+	h2 ^= h2 >> 33
+	return h1, h2 | 1, uint64(len(b.bits)) * 64
+}
+
+func (b *bloomFilter) add(key string) {
+	h1, h2, m := b.locations(key)
+	for i := uint64(0); i < b.k; i++ {
+		bit := (h1 + i*h2) % m
+		b.bits[bit/64] |= 1 << (bit % 64)
+	}
+}
+
+func (b *bloomFilter) mayContain(key string) bool {
+	h1, h2, m := b.locations(key)
+	for i := uint64(0); i < b.k; i++ {
+		bit := (h1 + i*h2) % m
+		if b.bits[bit/64]&(1<<(bit%64)) == 0 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,152 @@
+package LCache_go
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// BreakerOptions configure the circuit breaker around the loader, see
+// CacheOptions.LoaderBreaker. Zero fields take the defaults noted.
+
+type BreakerOptions struct {
+	FailureRatio float64       // Failed share of loads in a window that opens the breaker, defaults to 0.5
+	MinRequests  int           // Loads a window needs before the ratio counts, defaults to 20
+	Window       time.Duration // How long failures are counted before starting over, defaults to 10s
+	OpenTimeout  time.Duration // How long the breaker stays open before a probe load, defaults to 5s
+}
+
+func (o BreakerOptions) validate() error {
+	if o.FailureRatio < 0 || o.FailureRatio > 1 {
+		return fmt.Errorf("lcache: breaker FailureRatio must be between 0 and 1, got %v", o.FailureRatio)
+	}
+	if o.MinRequests < 0 || o.Window < 0 || o.OpenTimeout < 0 {
+		return fmt.Errorf("lcache: breaker options must not be negative")
+	}
+	return nil
+}
+
+type breakerState int
+
+const (
+	breakerClosed breakerState = iota
+	breakerOpen
+	breakerHalfOpen
+)
+
+func (s breakerState) String() string {
+	switch s {
+	case breakerOpen:
+		return "open"
+	case breakerHalfOpen:
+		return "half_open"
+	default:
+		return "closed"
+	}
+}
+
+// breaker is a closed/open/half-open circuit breaker: closed counts loads in
+// fixed windows and opens once the failure ratio is reached, open rejects
+// every load until OpenTimeout has passed, half-open lets a single probe
+// through whose outcome closes or reopens the breaker
+
+type breaker struct {
+	opts BreakerOptions
+
+	mu          sync.Mutex
+	state       breakerState
+	windowStart time.Time
+	requests    int
+	failures    int
+	openedAt    time.Time
+	probing     bool
+	opens       int64
+	rejected    int64
+}
+
+func newBreaker(opts BreakerOptions) *breaker {
+	if opts.FailureRatio == 0 {
+		opts.FailureRatio = 0.5
+	}
+	if opts.MinRequests == 0 {
+		opts.MinRequests = 20
+	}
+	if opts.Window == 0 {
+		opts.Window = 10 * time.Second
+	}
+	if opts.OpenTimeout == 0 {
+		opts.OpenTimeout = 5 * time.Second
+	}
+	return &breaker{opts: opts, windowStart: time.Now()}
+}
+
+// allow reports whether a load may run, every allowed load must be followed by done
+func (b *breaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	switch b.state {
+	case breakerOpen:
+		if now.Sub(b.openedAt) < b.opts.OpenTimeout {
+			b.rejected++
+			return false
+		}
+		b.state = breakerHalfOpen
+		fallthrough
+	case breakerHalfOpen:
+		if b.probing {
+			b.rejected++
+			return false
+		}
+		b.probing = true
+		return true
+	}
+	if now.Sub(b.windowStart) >= b.opts.Window {
+		b.windowStart, b.requests, b.failures = now, 0, 0
+	}
+	return true
+}
+
+// done records the outcome of an allowed load
+func (b *breaker) done(failed bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	if b.state == breakerHalfOpen {
+		b.probing = false
+		if failed {
+			b.open(now)
+		} else {
+			b.state = breakerClosed
+			b.windowStart, b.requests, b.failures = now, 0, 0
+		}
+		return
+	}
+	if b.state != breakerClosed {
+		return
+	}
+	b.requests++
+	if failed {
+		b.failures++
+	}
+	if b.requests >= b.opts.MinRequests && float64(b.failures) >= b.opts.FailureRatio*float64(b.requests) {
+		b.open(now)
+	}
+}
+
+// open trips the breaker, need to hold the lock
+func (b *breaker) open(now time.Time) {
+	b.state = breakerOpen
+	b.openedAt = now
+	b.opens++
+}
+
+func (b *breaker) stats(stats Stats) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	stats["loader_breaker_state"] = b.state.String()
+	stats["loader_breaker_opens"] = b.opens
+	stats["loader_breaker_rejected"] = b.rejected
+}
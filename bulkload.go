@@ -0,0 +1,125 @@
+package LCache_go
+
+import (
+	"lcache/store"
+	"sync"
+	"sync/atomic"
+)
+
+// BulkLoad is a declared bulk-load phase started by Cache.BeginBulkLoad
+
+type BulkLoad struct {
+	c        *Cache
+	overflow func(key string, value ByteView)
+	mu       sync.Mutex
+	pending  []KeyEvent // evictions not yet handed to overflow, never dropped
+	wake     chan struct{}
+	done     chan struct{}
+	spilled  int64
+}
+
+// BeginBulkLoad starts a bulk-load phase, e.g. a warmup. Until End, every entry
+// evicted to make room is handed to overflow instead of being dropped silently,
+// so it can be spilled to another tier. overflow runs on its own goroutine in
+// eviction order and may call back into the cache. Only one bulk load can be
+// active at a time; stores without events (see store.Notifier) return ErrNotSupported.
+func (c *Cache) BeginBulkLoad(overflow func(key string, value ByteView)) (*BulkLoad, error) {
+	if !OpenedAndInitialized(c) {
+		return nil, ErrCacheClosed
+	}
+	c.mu.RLock()
+	_, ok := c.store.(store.Notifier)
+	c.mu.RUnlock()
+	if !ok {
+		return nil, ErrNotSupported
+	}
+
+	c.bulkMu.Lock()
+	defer c.bulkMu.Unlock()
+	if c.bulk != nil {
+		return nil, ErrBulkLoadActive
+	}
+	b := &BulkLoad{
+		c:        c,
+		overflow: overflow,
+		wake:     make(chan struct{}, 1),
+		done:     make(chan struct{}),
+	}
+	c.bulk = b
+	done := c.res.acquire(resGoroutine, "bulk_load")
+	go func() {
+		defer done()
+		b.run()
+	}()
+	return b, nil
+}
+
+// End finishes the bulk load, waits until overflow has seen every eviction of
+// the phase and returns how many entries it was given. Calling End again only
+// returns the count.
+func (b *BulkLoad) End() int64 {
+	b.c.endBulkLoad(b)
+	<-b.done
+	return atomic.LoadInt64(&b.spilled)
+}
+
+// Spilled returns how many entries have been handed to overflow so far
+func (b *BulkLoad) Spilled() int64 {
+	return atomic.LoadInt64(&b.spilled)
+}
+
+// add queues an eviction, c.bulkMu must be held
+func (b *BulkLoad) add(ev KeyEvent) {
+	b.mu.Lock()
+	b.pending = append(b.pending, ev)
+	b.mu.Unlock()
+	select {
+	case b.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (b *BulkLoad) run() {
+	defer close(b.done)
+	for range b.wake {
+		b.drain()
+	}
+	b.drain()
+}
+
+func (b *BulkLoad) drain() {
+	for {
+		b.mu.Lock()
+		batch := b.pending
+		b.pending = nil
+		b.mu.Unlock()
+		if len(batch) == 0 {
+			return
+		}
+		for _, ev := range batch {
+			b.overflow(ev.Key, ev.Value)
+			atomic.AddInt64(&b.spilled, 1)
+		}
+	}
+}
+
+// endBulkLoad detaches b, or whichever bulk load is active when b is nil, so no
+// more evictions reach it. It doesn't wait for overflow.
+func (c *Cache) endBulkLoad(b *BulkLoad) {
+	c.bulkMu.Lock()
+	defer c.bulkMu.Unlock()
+	if c.bulk == nil || (b != nil && c.bulk != b) {
+		return
+	}
+	close(c.bulk.wake)
+	c.bulk = nil
+}
+
+// spill hands an eviction to the active bulk load, if any
+func (c *Cache) spill(ev KeyEvent) {
+	c.bulkMu.Lock()
+	defer c.bulkMu.Unlock()
+	if c.bulk != nil {
+		c.bulk.add(ev)
+	}
+}
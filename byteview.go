@@ -1,9 +1,12 @@
 package LCache_go
 
+import "hash/fnv"
+
 // readonly byte slice view for cache data
 
 type ByteView struct {
-	b []byte
+	b      []byte
+	writer string // who stored the value, see CacheOptions.TrackWriters
 }
 
 func (b ByteView) Len() int {
@@ -21,3 +24,15 @@ func cloneBytes(b []byte) []byte {
 	copy(rs, b)
 	return rs
 }
+
+// NewByteView returns a view holding a copy of b
+func NewByteView(b []byte) ByteView {
+	return ByteView{b: cloneBytes(b)}
+}
+
+// Hash returns the 64-bit FNV-1a hash of the bytes, usable as an ETag
+func (b ByteView) Hash() uint64 {
+	h := fnv.New64a()
+	h.Write(b.b)
+	return h.Sum64()
+}
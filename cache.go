@@ -1,17 +1,15 @@
 package LCache_go
 
 import (
-	"go.uber.org/zap"
+	"context"
+	"errors"
+	"fmt"
 	"lcache/store"
 	"sync"
 	"sync/atomic"
 	"time"
 )
 
-var (
-	logger, _ = zap.NewProduction()
-)
-
 // encapsulates a cache entry
 
 type Cache struct {
@@ -20,30 +18,355 @@ type Cache struct {
 	store       store.Store
 	hits        int64
 	misses      int64
+	timeouts    int64 // Set and Delete calls that gave up waiting on the store
+	codecErrors int64 // EncodeValue and DecodeValue failures
+	corrupt     int64 // values that failed their checksum
 	initialized int32
 	closed      int32
+	paused      int32 // maintenance paused, see PauseMaintenance
+	draining    int32 // see MarkDraining
+	window      *rollingStats
+	latency     *latencyTracker
+	valueSizes  Histogram // sizes of values written to the cache
+	statsStop   chan struct{}
+	logger      *cacheLogger
+
+	loads        flightGroup
+	batcher      *batcher       // nil unless BatchLoader is set
+	breaker      *breaker       // nil unless LoaderBreaker is set
+	fallbacks    *fallbackChain // nil unless FallbackLoaders is set
+	rules        []compiledRule
+	absent       *absentFilter  // nil unless AbsentFilter is set
+	shadow       *shadowCache   // nil unless Shadow is set
+	storeFaults  *faultInjector // nil unless StoreFaults is set
+	loaderFaults *faultInjector // nil unless LoaderFaults is set
+	cipher       *valueCipher   // nil unless EncryptionKey is set
+	ttlSupported bool           // the store honors expirations, see store.Capabilities
+	loadCount    int64
+	loadsDeduped int64
+	loadErrors   int64
+	loadsShed    int64
+	loadRetries  int64
+	loading      int64 // loads running right now, see shouldShed
+	inflight     int64 // writes and loads that Close waits for
+
+	limits     rateLimits      // per-namespace rate limits
+	events     eventBus        // store events for Watch, Subscribe, OnEvicted and OnExpired
+	topKeys    *topKeys        // nil unless TrackTopKeys is set
+	res        resourceTracker // goroutines, tickers and files owned by the cache
+	snapshots  snapshotStatus  // outcome of the last snapshot save and restore, see Report
+	tombstones tombstones      // keys deleted with DeleteWithDelay
+	delayed    delayedDeletes  // second deletes of InvalidateTwice
+	bulkMu     sync.Mutex
+	bulk       *BulkLoad // active bulk load, see BeginBulkLoad
+
+	// settings that can change at runtime, see ApplyOptions
+	maxBytes   int64
+	defaultTTL int64
 }
 
 type CacheOptions struct {
-	CacheType   store.CacheType // Type of cache, e.g., LRU, LRU2
-	MaxBytes    int64
-	CleanupTime time.Duration
-	OnEvicted   func(key string, value store.Value) // Callback when an item is evicted
+	Name            string            // Identifies the cache in logs, stats, events and the admin API
+	Labels          map[string]string // Extra dimensions attached alongside Name, e.g. {"tier": "sessions"}
+	CacheType       store.CacheType   // Type of cache, e.g., LRU, LRU2
+	MaxBytes        int64
+	MaxEntryBytes   int64                               // Largest value accepted by Set, 0 means no limit
+	CleanupTime     time.Duration                       // How often the store removes expired entries, 0 disables background cleanup
+	AdaptiveCleanup bool                                // Runs cleanup more often, down to CleanupTime/16, while passes keep finding expired entries
+	EvictionBatch   int                                 // Most entries evicted per store lock hold, 0 uses store.DefaultEvictionBatch
+	AsyncEviction   bool                                // Evict from a background goroutine instead of inline in Set, see store.Options
+	HighWatermark   float64                             // Fraction of MaxBytes that wakes the async evictor, defaults to 0.95
+	LowWatermark    float64                             // Fraction of MaxBytes the async evictor evicts down to, defaults to 0.85
+	PauseMaxBytes   int64                               // Usage that still triggers eviction while maintenance is paused, 0 means twice MaxBytes
+	SetTimeout      time.Duration                       // Longest Set waits on the store before returning ErrTimeout, 0 means no limit
+	DeleteTimeout   time.Duration                       // Longest Delete waits on the store before returning ErrTimeout, 0 means no limit
+	DefaultTTL      time.Duration                       // Applied to values stored without a ttl, 0 means they don't expire
+	OnEvicted       func(key string, value store.Value) // Called asynchronously, in order and without drops, when an item is evicted to make room
+	OnExpired       func(key string, value store.Value) // Called like OnEvicted when an item's TTL lapses, on access or by cleanup; not for evictions
+	Store           store.Store                         // Used instead of building a store from CacheType, e.g. store.NewFake() in tests or store.Downgrade(v2)
+
+	Loader        LoaderFunc    // Fills misses in Get/GetCtx, nil disables loading
+	LoaderTimeout time.Duration // Upper bound for a single Loader or BatchLoader call, 0 means no limit
+
+	// FallbackLoaders are tried in order on a miss, e.g. replica, then primary,
+	// then a static default; exclusive with Loader and BatchLoader
+	FallbackLoaders []FallbackLoader
+
+	MaxConcurrentLoads int        // Loads running at once before LoadShedding applies, 0 disables shedding
+	LoadShedding       ShedPolicy // What happens to new loads beyond MaxConcurrentLoads, fails them with ErrLoadShed
+
+	LoaderBreaker *BreakerOptions // Stops calling a failing loader for a while, failing misses with ErrBreakerOpen; nil disables
+	LoaderRetry   *RetryPolicy    // Retries failed loader calls with exponential backoff, nil disables
+
+	// AbsentFilter remembers keys the loader reported missing in a rotating
+	// bloom filter, so repeated misses on them skip the loader. A key written
+	// later is served from the cache as usual, but once evicted it may still be
+	// reported missing until it rotates out of the filter.
+	AbsentFilter *AbsentFilterOptions
+
+	// Shadow mirrors Get, Set, Delete and Clear traffic into a second store
+	// with its own CacheType and MaxBytes and reports the hit rate it would
+	// have had ("shadow_hit_rate" and friends in Stats), so a policy or size
+	// can be tried on production traffic. It never serves values and holds only
+	// key and size placeholders; transactions bypass it.
+	Shadow *ShadowOptions
+
+	// StoreFaults and LoaderFaults inject latency, errors (ErrInjectedFault),
+	// spurious misses and, for the store, silently dropped writes into cache
+	// reads, writes and deletes and into loader calls, to test how an
+	// application copes with a degraded cache. For tests only; nil disables.
+	StoreFaults  *FaultOptions
+	LoaderFaults *FaultOptions
+
+	BatchLoader  BatchLoaderFunc // Like Loader but coalesces misses into one call, exclusive with Loader
+	BatchWindow  time.Duration   // How long a batch collects misses, defaults to 2ms
+	MaxBatchSize int             // Sends a batch early once it has this many keys, 0 means no limit
+
+	// KeyTransform maps every key before it reaches the store, e.g. lowercasing
+	// or canonicalizing URLs, so callers don't have to. It is applied exactly
+	// once per call and must be deterministic. Loaders still get the caller's
+	// key, concurrent loads of keys that transform alike are deduplicated.
+	// Prefix and pattern arguments are not transformed.
+	KeyTransform func(key string) string
+
+	// EncodeValue and DecodeValue transform values on their way into and out of
+	// the store, e.g. to encrypt, compress or redact them; set both or neither.
+	// key is the key as stored. Sizes, limits and snapshots see encoded values,
+	// Get, Inspect, transactions, views and events see decoded ones. The hooks
+	// must not modify value in place, and DecodeValue runs on every read.
+	EncodeValue func(key string, value []byte) ([]byte, error)
+	DecodeValue func(key string, value []byte) ([]byte, error)
+
+	// EncryptionKey keeps values AES-GCM encrypted in memory, so heap dumps and
+	// snapshots don't expose them; they are decrypted only when read. It must be
+	// 16, 24 or 32 bytes and applies after EncodeValue. Keys are not encrypted,
+	// but each value is bound to its key, so Rename isn't supported.
+	EncryptionKey []byte
+
+	// VerifyChecksums stores a CRC32 of each value and checks it on every read.
+	// A corrupt value reads as ErrValueCorrupt and is evicted. Snapshots must be
+	// restored with the same setting, and with the same EncryptionKey.
+	VerifyChecksums bool
+
+	// TrackWriters records who last wrote each key: the label passed to SetCtx
+	// with WithWriter, or "loader" for loaded values. It shows
+	// as EntryInfo.Writer in Inspect, Scan, Sample and the admin API, and is
+	// kept in memory only, not in snapshots.
+	TrackWriters bool
+
+	// FingerprintKeys stores a 128-bit hash of each key (after KeyTransform)
+	// instead of the key itself, for workloads with long keys; collisions are negligible but
+	// possible. Prefix and pattern operations (CountPrefix, Scan, FlushPattern,
+	// Watch prefixes, namespace Len) and events then see fingerprints, and
+	// SetQuota and ClearNamespace fail with ErrNotSupported.
+	FingerprintKeys bool
+
+	// Rules give key families their own TTL and size limits, the first rule
+	// matching a key applies
+	Rules []Rule
+
+	TrackTopKeys int // Number of hot keys tracked for TopKeys, 0 disables tracking
+
+	SnapshotPath string // Restored when the cache initializes and written by CloseContext, empty disables
+
+	StatsReporter func(Stats)   // Called with a Stats snapshot every StatsInterval
+	StatsInterval time.Duration // Defaults to one minute
+
+	Logger          Logger   // nil disables logging
+	LogLevel        LogLevel // Records below this level are dropped, defaults to LevelInfo
+	QuietOperations bool     // Suppress per-call Get/Set/Delete records
 }
 
 func DefaultCacheOptions() CacheOptions {
 	return CacheOptions{
+		Name:        "default",
 		MaxBytes:    8 * 1024 * 1024, // 8MB
 		CleanupTime: time.Minute,
 		CacheType:   store.LRU,
 		OnEvicted:   nil,
+
+		StatsInterval: defaultStatsInterval,
+		Logger:        defaultLogger(),
+	}
+}
+
+// Validate reports the first invalid setting in o
+func (o CacheOptions) Validate() error {
+	switch o.CacheType {
+	case store.LRU, "": // LRU2 isn't implemented yet
+	default:
+		return fmt.Errorf("lcache: unknown CacheType %q", o.CacheType)
+	}
+	if err := o.storeOptions().Validate(); err != nil {
+		return err
+	}
+	if o.MaxEntryBytes < 0 {
+		return fmt.Errorf("lcache: MaxEntryBytes must not be negative, got %d", o.MaxEntryBytes)
+	}
+	if o.MaxBytes > 0 && o.MaxEntryBytes > o.MaxBytes {
+		return fmt.Errorf("lcache: MaxEntryBytes %d exceeds MaxBytes %d", o.MaxEntryBytes, o.MaxBytes)
+	}
+	if o.DefaultTTL < 0 {
+		return fmt.Errorf("lcache: DefaultTTL must not be negative, got %v", o.DefaultTTL)
+	}
+	if o.PauseMaxBytes < 0 {
+		return fmt.Errorf("lcache: PauseMaxBytes must not be negative, got %d", o.PauseMaxBytes)
+	}
+	if o.SetTimeout < 0 {
+		return fmt.Errorf("lcache: SetTimeout must not be negative, got %v", o.SetTimeout)
+	}
+	if o.DeleteTimeout < 0 {
+		return fmt.Errorf("lcache: DeleteTimeout must not be negative, got %v", o.DeleteTimeout)
+	}
+	if o.LoaderTimeout < 0 {
+		return fmt.Errorf("lcache: LoaderTimeout must not be negative, got %v", o.LoaderTimeout)
+	}
+	if o.MaxConcurrentLoads < 0 {
+		return fmt.Errorf("lcache: MaxConcurrentLoads must not be negative, got %d", o.MaxConcurrentLoads)
+	}
+	if o.LoadShedding < ShedNone || o.LoadShedding > ShedProbabilistic {
+		return fmt.Errorf("lcache: invalid LoadShedding %d", o.LoadShedding)
+	}
+	if o.LoaderBreaker != nil {
+		if err := o.LoaderBreaker.validate(); err != nil {
+			return err
+		}
+	}
+	if o.LoaderRetry != nil {
+		if err := o.LoaderRetry.validate(); err != nil {
+			return err
+		}
+	}
+	if o.AbsentFilter != nil {
+		if err := o.AbsentFilter.validate(); err != nil {
+			return err
+		}
+	}
+	if o.Shadow != nil {
+		if err := o.Shadow.validate(); err != nil {
+			return err
+		}
+	}
+	for _, f := range []*FaultOptions{o.StoreFaults, o.LoaderFaults} {
+		if f != nil {
+			if err := f.validate(); err != nil {
+				return err
+			}
+		}
+	}
+	if (o.EncodeValue == nil) != (o.DecodeValue == nil) {
+		return errors.New("lcache: EncodeValue and DecodeValue must be set together")
+	}
+	switch len(o.EncryptionKey) {
+	case 0, 16, 24, 32:
+	default:
+		return fmt.Errorf("lcache: EncryptionKey must be 16, 24 or 32 bytes, got %d", len(o.EncryptionKey))
+	}
+	if o.Loader != nil && o.BatchLoader != nil {
+		return errors.New("lcache: Loader and BatchLoader are exclusive")
+	}
+	if len(o.FallbackLoaders) > 0 {
+		if o.Loader != nil || o.BatchLoader != nil {
+			return errors.New("lcache: FallbackLoaders are exclusive with Loader and BatchLoader")
+		}
+		if err := validateFallbacks(o.FallbackLoaders); err != nil {
+			return err
+		}
+	}
+	if o.BatchWindow < 0 {
+		return fmt.Errorf("lcache: BatchWindow must not be negative, got %v", o.BatchWindow)
 	}
+	if o.MaxBatchSize < 0 {
+		return fmt.Errorf("lcache: MaxBatchSize must not be negative, got %d", o.MaxBatchSize)
+	}
+	if _, err := compileRules(o.Rules); err != nil {
+		return err
+	}
+	if o.TrackTopKeys < 0 {
+		return fmt.Errorf("lcache: TrackTopKeys must not be negative, got %d", o.TrackTopKeys)
+	}
+	if o.StatsInterval < 0 {
+		return fmt.Errorf("lcache: StatsInterval must not be negative, got %v", o.StatsInterval)
+	}
+	if o.LogLevel < LevelDebug || o.LogLevel > LevelError {
+		return fmt.Errorf("lcache: invalid LogLevel %d", o.LogLevel)
+	}
+	return nil
+}
+
+func (o CacheOptions) storeOptions() store.Options {
+	return store.Options{
+		MaxBytes:        o.MaxBytes,
+		CleanupInterval: o.CleanupTime,
+		AdaptiveCleanup: o.AdaptiveCleanup,
+		EvictionBatch:   o.EvictionBatch,
+		AsyncEviction:   o.AsyncEviction,
+		HighWatermark:   o.HighWatermark,
+		LowWatermark:    o.LowWatermark,
+	}
+}
+
+// newStore returns o.Store when set, otherwise builds one from CacheType
+func (o CacheOptions) newStore() (store.Store, error) {
+	if o.Store != nil {
+		return o.Store, nil
+	}
+	return store.NewStore(o.CacheType, o.storeOptions())
 }
 
-func NewCache(opts CacheOptions) *Cache {
-	return &Cache{
-		opts: opts,
+func NewCache(opts CacheOptions) (*Cache, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	if opts.Store != nil {
+		if err := opts.checkCapabilities(store.CapabilitiesOf(opts.Store)); err != nil {
+			return nil, err
+		}
+	}
+	opts.Labels = copyLabels(opts.Labels)
+	c := &Cache{
+		opts:    opts,
+		window:  newRollingStats(),
+		latency: newLatencyTracker(),
+		logger:  newCacheLogger(opts.Logger, opts.Name, opts.Labels, opts.LogLevel),
+
+		maxBytes:   opts.MaxBytes,
+		defaultTTL: int64(opts.DefaultTTL),
+	}
+	if opts.TrackTopKeys > 0 {
+		c.topKeys = newTopKeys(opts.TrackTopKeys)
+	}
+	if opts.BatchLoader != nil {
+		c.batcher = newBatcher(opts)
+		c.batcher.res = &c.res
+	}
+	if opts.LoaderBreaker != nil {
+		c.breaker = newBreaker(*opts.LoaderBreaker)
+	}
+	if len(opts.FallbackLoaders) > 0 {
+		c.fallbacks = newFallbackChain(opts.FallbackLoaders)
 	}
+	c.rules, _ = compileRules(opts.Rules)
+	if opts.AbsentFilter != nil {
+		c.absent = newAbsentFilter(*opts.AbsentFilter)
+	}
+	if opts.Shadow != nil {
+		c.shadow = newShadowCache(*opts.Shadow, opts)
+	}
+	if opts.StoreFaults != nil {
+		c.storeFaults = newFaultInjector(*opts.StoreFaults)
+	}
+	if opts.LoaderFaults != nil {
+		c.loaderFaults = newFaultInjector(*opts.LoaderFaults)
+	}
+	if len(opts.EncryptionKey) > 0 {
+		vc, err := newValueCipher(opts.EncryptionKey)
+		if err != nil {
+			return nil, err
+		}
+		c.cipher = vc
+	}
+	return c, nil
 }
 
 func (c *Cache) ensureCacheInitialized() {
@@ -56,96 +379,273 @@ func (c *Cache) ensureCacheInitialized() {
 	defer c.mu.Unlock()
 
 	if c.initialized == 0 {
-		c.store = store.NewStore(c.opts.CacheType, store.Options{
-			MaxBytes:        c.opts.MaxBytes,
-			CleanupInterval: c.opts.CleanupTime,
-		})
+		s, err := c.opts.newStore()
+		if err != nil {
+			c.logger.Error("Failed to create store", "cacheType", string(c.opts.CacheType), "error", err)
+			return
+		}
+		c.ttlSupported = store.CapabilitiesOf(s).TTL
+		c.store = s
+		if n, ok := s.(store.Notifier); ok {
+			n.SetListener(c.onStoreEvent)
+		}
+		if c.opts.OnEvicted != nil {
+			c.startCallback(EventEvict, "evicted_callback", c.opts.OnEvicted)
+		}
+		if c.opts.OnExpired != nil {
+			c.startCallback(EventExpire, "expired_callback", c.opts.OnExpired)
+		}
+		if c.shadow != nil {
+			if err := c.shadow.open(c.opts.CleanupTime); err != nil {
+				c.logger.Error("Failed to create shadow store", "cacheType", string(c.shadow.opts.CacheType), "error", err)
+				c.shadow = nil
+			}
+		}
+		if c.opts.SnapshotPath != "" {
+			if err := c.loadSnapshotFile(c.opts.SnapshotPath, c.store); err != nil {
+				c.logger.Warn("Failed to restore snapshot", "path", c.opts.SnapshotPath, "error", err)
+			}
+		}
+		if c.opts.StatsReporter != nil {
+			c.startStatsReporter()
+		}
 		atomic.StoreInt32(&c.initialized, 1)
-		logger.Info("Cache initialized", zap.String("cacheType", string(c.opts.CacheType)),
-			zap.Int64("maxBytes", c.opts.MaxBytes))
+		c.logger.Info("Cache initialized", "cacheType", string(c.opts.CacheType),
+			"maxBytes", c.opts.MaxBytes)
 	}
 }
 func OpenedAndInitialized(c *Cache) bool {
 	if atomic.LoadInt32(&c.closed) == 1 {
-		logger.Error("Cache is closed")
+		c.logger.Error("Cache is closed")
 		return false
 	}
 	c.ensureCacheInitialized()
 	return true
 }
 
+func (c *Cache) recordHit() {
+	atomic.AddInt64(&c.hits, 1)
+	c.window.record(true)
+}
+
+func (c *Cache) recordMiss() {
+	atomic.AddInt64(&c.misses, 1)
+	c.window.record(false)
+}
+
 func (c *Cache) Get(key string) (ByteView, bool) {
+	bv, err := c.GetCtx(context.Background(), key)
+	return bv, err == nil
+}
+
+// Lookup reads key from the cache only, without consulting the Loader, and reports
+// why a value is missing: ErrCacheClosed or ErrKeyNotFound
+func (c *Cache) Lookup(key string) (ByteView, error) {
+	defer c.latency.observe(OpGet, time.Now())
 	if !OpenedAndInitialized(c) {
-		atomic.AddInt64(&c.misses, 1)
-		return ByteView{}, false
+		c.recordMiss()
+		return ByteView{}, ErrCacheClosed
+	}
+
+	if c.topKeys != nil {
+		c.topKeys.record(key)
 	}
 
 	c.mu.RLock()
 	defer c.mu.RUnlock()
-	value, ok := c.store.Get(key)
+	if c.store == nil {
+		c.recordMiss()
+		return ByteView{}, ErrCacheClosed
+	}
+	sk := c.storeKey(key)
+	if c.shadow != nil {
+		c.shadow.get(sk)
+	}
+	if c.storeFaults != nil {
+		if err := c.storeFaults.inject(context.Background()); err != nil {
+			c.recordMiss()
+			return ByteView{}, err
+		}
+	}
+	value, ok := c.store.Get(sk)
 	if !ok {
-		atomic.AddInt64(&c.misses, 1)
-		return ByteView{}, false
+		c.recordMiss()
+		return ByteView{}, ErrKeyNotFound
 	}
 	if bv, ok := value.(ByteView); ok {
-		atomic.AddInt64(&c.hits, 1)
-		return bv, true
+		bv, err := c.decodeValue(sk, bv)
+		if err != nil {
+			c.opLog(LevelWarn, "Failed to decode value", OpGet, key, "error", err)
+			c.dropCorrupt(sk, err)
+			c.recordMiss()
+			return ByteView{}, err
+		}
+		c.recordHit()
+		return bv, nil
 	} else {
-		logger.Warn("Type assertion failed for key", zap.String("key", key), zap.String("expectedType", "ByteView"))
-		atomic.AddInt64(&c.misses, 1)
-		return ByteView{}, false
+		c.opLog(LevelWarn, "Type assertion failed for key", OpGet, key, "expectedType", "ByteView")
+		c.recordMiss()
+		return ByteView{}, ErrKeyNotFound
 	}
 }
 
 func (c *Cache) Add(key string, value ByteView) {
-	if !OpenedAndInitialized(c) {
-		logger.Warn("Attempted to add to a closed or uninitialized cache", zap.String("key", key))
-		return
-	}
-	// add lock or not?
-	//c.mu.Lock()
-	//defer c.mu.Unlock()
-	if err := c.store.Set(key, value); err != nil {
-		logger.Warn("Failed to add key to cache", zap.String("key", key), zap.Error(err))
+	if err := c.Set(key, value); err != nil {
+		c.opLog(LevelWarn, "Failed to add key to cache", OpSet, key, "error", err)
 	}
 }
 
 func (c *Cache) AddWithExpiration(key string, value ByteView, expirationTime time.Time) {
+	if err := c.SetWithTTL(key, value, time.Until(expirationTime)); err != nil {
+		c.opLog(LevelWarn, "Failed to add key with expiration to cache", OpSet, key, "error", err)
+	}
+}
+
+// Set stores value for DefaultTTL, or without expiration if it isn't set
+func (c *Cache) Set(key string, value ByteView) error {
+	return c.set(context.Background(), key, value, 0)
+}
+
+// SetWithTTL stores value for ttl, ttl must be positive
+func (c *Cache) SetWithTTL(key string, value ByteView, ttl time.Duration) error {
+	if ttl <= 0 {
+		return fmt.Errorf("%w: %v", ErrInvalidTTL, ttl)
+	}
+	return c.set(context.Background(), key, value, ttl)
+}
+
+func (c *Cache) set(ctx context.Context, key string, value ByteView, ttl time.Duration) error {
+	defer c.latency.observe(OpSet, time.Now())
 	if !OpenedAndInitialized(c) {
-		logger.Warn("Attempted to add with expiration to a closed or uninitialized cache", zap.String("key", key))
-		return
+		return ErrCacheClosed
 	}
-	expiration := time.Until(expirationTime)
-	if expiration <= 0 {
-		logger.Warn("Expiration time must be in the future", zap.String("key", key), zap.Duration("expiration", expiration))
-		return
+	if err := c.checkSize(key, value); err != nil {
+		return err
 	}
-	if err := c.store.SetWithExpiration(key, value, expiration); err != nil {
-		logger.Warn("Failed to add key with expiration to cache", zap.String("key", key), zap.Error(err))
+
+	atomic.AddInt64(&c.inflight, 1)
+	defer atomic.AddInt64(&c.inflight, -1)
+	// a graceful close may have started after the check above
+	if atomic.LoadInt32(&c.closed) == 1 {
+		return ErrCacheClosed
+	}
+	return c.bounded(ctx, c.opts.SetTimeout, func() error {
+		return c.storeValue(key, value, ttl, c.writerFrom(ctx))
+	})
+}
+
+// storeValue writes to the store even while a graceful close is draining,
+// attributing the value to writer when it isn't empty
+func (c *Cache) storeValue(key string, value ByteView, ttl time.Duration, writer string) error {
+	ttl = c.ttlFor(key, ttl)
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.store == nil {
+		return ErrCacheClosed
+	}
+	if ttl > 0 && !c.ttlSupported {
+		return fmt.Errorf("%w: store can't expire %q", ErrNotSupported, key)
+	}
+	sk := c.storeKey(key)
+	if err := c.checkTombstone(key, sk); err != nil {
+		return err
+	}
+	value, err := c.encodeValue(sk, value)
+	if err != nil {
+		return err
+	}
+	value.writer = writer
+	if drop, err := c.writeFault(key); drop || err != nil {
+		return err
+	}
+	if err := c.store.SetWithExpiration(sk, value, ttl); err != nil {
+		return fmt.Errorf("lcache: set %q: %w", key, err)
+	}
+	c.valueSizes.Record(int64(value.Len()))
+	if c.shadow != nil {
+		c.shadow.set(sk, value.Len(), ttl)
 	}
+	return nil
+}
+
+// writeFault applies StoreFaults to a write of key, drop means the write should
+// be skipped while reporting success
+func (c *Cache) writeFault(key string) (drop bool, err error) {
+	if c.storeFaults == nil {
+		return false, nil
+	}
+	c.storeFaults.delay(context.Background())
+	if err := c.storeFaults.fail(); err != nil {
+		return false, fmt.Errorf("lcache: set %q: %w", key, err)
+	}
+	return c.storeFaults.drop(), nil
+}
+
+// checkSize rejects values larger than MaxEntryBytes, or the limit of the rule
+// matching key, or the whole cache
+func (c *Cache) checkSize(key string, value ByteView) error {
+	size := int64(value.Len())
+	limit := c.opts.MaxEntryBytes
+	if r := c.ruleFor(key); r != nil && r.MaxEntryBytes > 0 {
+		limit = r.MaxEntryBytes
+	}
+	if limit > 0 && size > limit {
+		return fmt.Errorf("%w: %d bytes exceeds MaxEntryBytes %d", ErrValueTooLarge, size, limit)
+	}
+	if maxBytes := atomic.LoadInt64(&c.maxBytes); maxBytes > 0 && size > maxBytes {
+		return fmt.Errorf("%w: %d bytes exceeds MaxBytes %d", ErrValueTooLarge, size, maxBytes)
+	}
+	return nil
 }
 
 func (c *Cache) Delete(key string) bool {
-	if atomic.LoadInt32(&c.closed) == 1 || atomic.LoadInt32(&c.initialized) == 0 {
-		logger.Warn("Attempted to delete from a closed cache", zap.String("key", key))
-		return false
+	err := c.Remove(key)
+	if err == ErrCacheClosed {
+		c.opLog(LevelWarn, "Attempted to delete from a closed cache", OpDelete, key)
 	}
+	return err == nil
+}
 
-	c.mu.RLock()
-	defer c.mu.RUnlock()
+// Remove deletes key, returning ErrKeyNotFound if it wasn't cached
+func (c *Cache) Remove(key string) error {
+	return c.remove(context.Background(), key)
+}
 
-	deleted := c.store.Delete(key)
-	if deleted {
-		logger.Info("Key deleted from cache", zap.String("key", key))
-	} else {
-		logger.Warn("Key not found for deletion", zap.String("key", key))
+func (c *Cache) remove(ctx context.Context, key string) error {
+	defer c.latency.observe(OpDelete, time.Now())
+	if atomic.LoadInt32(&c.closed) == 1 || atomic.LoadInt32(&c.initialized) == 0 {
+		return ErrCacheClosed
 	}
-	return deleted
+
+	return c.bounded(ctx, c.opts.DeleteTimeout, func() error {
+		c.mu.RLock()
+		defer c.mu.RUnlock()
+		if c.store == nil {
+			return ErrCacheClosed
+		}
+
+		sk := c.storeKey(key)
+		if c.storeFaults != nil {
+			c.storeFaults.delay(ctx)
+			if err := c.storeFaults.fail(); err != nil {
+				return fmt.Errorf("lcache: delete %q: %w", key, err)
+			}
+		}
+		if c.shadow != nil {
+			c.shadow.delete(sk)
+		}
+		if !c.store.Delete(sk) {
+			c.opLog(LevelDebug, "Key not found for deletion", OpDelete, key)
+			return ErrKeyNotFound
+		}
+		c.opLog(LevelDebug, "Key deleted from cache", OpDelete, key)
+		return nil
+	})
 }
 
 func (c *Cache) Clear() {
 	if atomic.LoadInt32(&c.closed) == 1 || atomic.LoadInt32(&c.initialized) == 0 {
-		logger.Warn("Attempted to clear a closed or uninitialized cache")
+		c.logger.Warn("Attempted to clear a closed or uninitialized cache")
 		return
 	}
 
@@ -153,15 +653,47 @@ func (c *Cache) Clear() {
 	defer c.mu.Unlock()
 
 	c.store.Clear()
-	logger.Info("Cache cleared")
+	if c.shadow != nil {
+		c.shadow.clear()
+	}
+	c.logger.Info("Cache cleared")
+	c.ResetStats()
+}
+
+// ResetStats zeroes hit/miss counters, windows and histograms without touching cached data
+func (c *Cache) ResetStats() {
 	atomic.StoreInt64(&c.hits, 0)
 	atomic.StoreInt64(&c.misses, 0)
-	logger.Info("Cache statistics reset")
+	atomic.StoreInt64(&c.timeouts, 0)
+	atomic.StoreInt64(&c.codecErrors, 0)
+	atomic.StoreInt64(&c.corrupt, 0)
+	atomic.StoreInt64(&c.loadCount, 0)
+	atomic.StoreInt64(&c.loadsDeduped, 0)
+	atomic.StoreInt64(&c.loadErrors, 0)
+	atomic.StoreInt64(&c.loadsShed, 0)
+	atomic.StoreInt64(&c.loadRetries, 0)
+	if c.batcher != nil {
+		atomic.StoreInt64(&c.batcher.batches, 0)
+	}
+	if c.fallbacks != nil {
+		c.fallbacks.reset()
+	}
+	if c.shadow != nil {
+		c.shadow.reset()
+	}
+	c.window.reset()
+	c.events.reset()
+	c.latency.reset()
+	c.valueSizes.Reset()
+	if c.topKeys != nil {
+		c.topKeys.reset()
+	}
+	c.logger.Info("Cache statistics reset")
 }
 
 func (c *Cache) Len() int {
 	if atomic.LoadInt32(&c.closed) == 1 || atomic.LoadInt32(&c.initialized) == 0 {
-		logger.Warn("Attempted to get length of a closed or uninitialized cache")
+		c.logger.Debug("Attempted to get length of a closed or uninitialized cache")
 		return 0
 	}
 
@@ -169,35 +701,191 @@ func (c *Cache) Len() int {
 	defer c.mu.RUnlock()
 
 	length := c.store.Len()
-	logger.Info("Cache length retrieved", zap.Int("length", length))
+	c.logger.Debug("Cache length retrieved", "length", length)
 	return length
 }
 
+// UsedBytes returns the number of bytes currently held by the underlying store
+func (c *Cache) UsedBytes() int64 {
+	if atomic.LoadInt32(&c.closed) == 1 || atomic.LoadInt32(&c.initialized) == 0 {
+		return 0
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.store.UsedBytes()
+}
+
 func (c *Cache) Close() {
 	if !atomic.CompareAndSwapInt32(&c.closed, 0, 1) {
-		logger.Warn("Cache is already closed")
+		c.logger.Warn("Cache is already closed")
 		return
 	}
+	c.release()
+}
+
+// CloseContext closes the cache gracefully: new calls are rejected right away,
+// in-flight writes and loads get until ctx is done to finish, and a final snapshot
+// is written to SnapshotPath when configured. Resources are released even if ctx
+// expires first, in which case ctx.Err() is returned.
+func (c *Cache) CloseContext(ctx context.Context) error {
+	if !atomic.CompareAndSwapInt32(&c.closed, 0, 1) {
+		return ErrCacheClosed
+	}
+	err := c.drain(ctx)
+
+	if c.opts.SnapshotPath != "" {
+		c.mu.RLock()
+		if c.store != nil {
+			if serr := c.saveSnapshotFile(c.opts.SnapshotPath, c.store); serr != nil {
+				c.logger.Error("Failed to write final snapshot", "path", c.opts.SnapshotPath, "error", serr)
+				err = errors.Join(err, serr)
+			}
+		}
+		c.mu.RUnlock()
+	}
+
+	c.release()
+	return err
+}
+
+// drain waits for in-flight writes and loads to finish
+func (c *Cache) drain(ctx context.Context) error {
+	ticker := time.NewTicker(5 * time.Millisecond)
+	defer ticker.Stop()
+	for atomic.LoadInt64(&c.inflight) > 0 {
+		select {
+		case <-ctx.Done():
+			c.logger.Warn("Close deadline reached with operations in flight", "inflight", atomic.LoadInt64(&c.inflight))
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+	return nil
+}
+
+// release tears down the store and background goroutines once closed is set
+func (c *Cache) release() {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
+	c.stopStatsReporter()
+	c.delayed.stopAll()
+	c.events.closeAll()
+	c.endBulkLoad(nil)
 	// check
 	if c.store != nil {
 		c.store.Close()
+		go c.checkLeaks(c.store)
 		c.store = nil
 	}
+	if c.shadow != nil {
+		c.shadow.close()
+	}
 	atomic.StoreInt32(&c.initialized, 0)
-	logger.Info("Cache closed and resources released")
-	logger.Info("Cache statistics", zap.Int64("hits", c.hits), zap.Int64("misses", c.misses))
+	c.logger.Info("Cache closed and resources released")
+	c.logger.Info("Cache statistics", "hits", c.hits, "misses", c.misses)
+}
+
+// Reopen makes a closed cache usable again with a fresh store, restored from
+// SnapshotPath when configured. Statistics
+// are kept; call ResetStats to start over. Reopening an open cache is a no-op.
+func (c *Cache) Reopen() error {
+	c.mu.Lock()
+	if atomic.LoadInt32(&c.closed) == 0 {
+		c.mu.Unlock()
+		return nil
+	}
+	if c.store != nil {
+		// Close has flipped the flag but not released the store yet
+		c.mu.Unlock()
+		return errors.New("lcache: close in progress")
+	}
+	atomic.StoreInt32(&c.closed, 0)
+	atomic.StoreInt32(&c.draining, 0)
+	c.mu.Unlock()
+
+	c.ensureCacheInitialized()
+	c.logger.Info("Cache reopened")
+	return nil
+}
+
+// Latency returns latency percentiles for one of OpGet, OpSet or OpDelete
+func (c *Cache) Latency(op string) LatencyStats {
+	return c.latency.stats(op)
+}
+
+// ValueSizes returns the distribution of value sizes written to the cache
+func (c *Cache) ValueSizes() []HistogramBucket {
+	return c.valueSizes.Buckets()
 }
 
-func (c *Cache) Stats() map[string]interface{} {
-	stats := map[string]interface{}{
+// storeCounters returns the store's own counters when it is a store.Reporter
+func (c *Cache) storeCounters() map[string]int64 {
+	if atomic.LoadInt32(&c.closed) == 1 || atomic.LoadInt32(&c.initialized) == 0 {
+		return nil
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if r, ok := c.store.(store.Reporter); ok {
+		return r.Counters()
+	}
+	return nil
+}
+
+// SetLogLevel changes the minimum level of records logged by the cache
+func (c *Cache) SetLogLevel(level LogLevel) {
+	c.logger.setLevel(level)
+}
+
+func (c *Cache) Stats() Stats {
+	stats := Stats{
 		"initialized": atomic.LoadInt32(&c.initialized) == 1,
 		"closed":      atomic.LoadInt32(&c.closed) == 1,
 		"hits":        atomic.LoadInt64(&c.hits),
 		"misses":      atomic.LoadInt64(&c.misses),
 		"size":        c.Len(),
+		"used_bytes":  c.UsedBytes(),
+		"max_bytes":   atomic.LoadInt64(&c.maxBytes),
+
+		"maintenance_paused": atomic.LoadInt32(&c.paused) == 1,
+	}
+	stats["name"] = c.opts.Name
+	if len(c.opts.Labels) > 0 {
+		stats["labels"] = copyLabels(c.opts.Labels)
+	}
+	stats["timeouts"] = atomic.LoadInt64(&c.timeouts)
+	stats["value_codec_errors"] = atomic.LoadInt64(&c.codecErrors)
+	stats["corrupt_values"] = atomic.LoadInt64(&c.corrupt)
+	stats["loads"] = atomic.LoadInt64(&c.loadCount)
+	stats["loads_deduped"] = atomic.LoadInt64(&c.loadsDeduped)
+	stats["load_errors"] = atomic.LoadInt64(&c.loadErrors)
+	stats["loads_shed"] = atomic.LoadInt64(&c.loadsShed)
+	stats["load_retries"] = atomic.LoadInt64(&c.loadRetries)
+	stats["loads_running"] = atomic.LoadInt64(&c.loading)
+	if c.breaker != nil {
+		c.breaker.stats(stats)
+	}
+	if c.fallbacks != nil {
+		c.fallbacks.stats(stats)
+	}
+	if c.absent != nil {
+		c.absent.stats(stats)
+	}
+	if c.shadow != nil {
+		c.shadow.stats(stats)
+	}
+	c.tombstones.stats(stats)
+	c.delayed.stats(stats)
+	if c.storeFaults != nil {
+		c.storeFaults.stats(stats, "store_faults")
+	}
+	if c.loaderFaults != nil {
+		c.loaderFaults.stats(stats, "loader_faults")
+	}
+	if c.batcher != nil {
+		stats["load_batches"] = atomic.LoadInt64(&c.batcher.batches)
 	}
 	totalRequests := stats["hits"].(int64) + stats["misses"].(int64)
 	if totalRequests > 0 {
@@ -205,6 +893,34 @@ func (c *Cache) Stats() map[string]interface{} {
 	} else {
 		stats["hit_rate"] = 0.0
 	}
+	for name, rate := range c.window.hitRates() {
+		stats["hit_rate_"+name] = rate
+	}
+	for _, op := range []string{OpGet, OpSet, OpDelete} {
+		l := c.latency.stats(op)
+		stats[op+"_latency_p50"] = l.P50
+		stats[op+"_latency_p95"] = l.P95
+		stats[op+"_latency_p99"] = l.P99
+	}
+	stats["value_size_p50"] = c.valueSizes.P50()
+	stats["value_size_p95"] = c.valueSizes.P95()
+	stats["value_size_p99"] = c.valueSizes.P99()
+	stats["value_size_max"] = c.valueSizes.Max()
+	stats["value_size_histogram"] = c.valueSizes.Buckets()
+	counters := c.storeCounters()
+	for name, v := range counters {
+		stats[name] = v
+	}
+	res := c.res.snapshot()
+	addStoreResources(res, counters)
+	resourceStats(stats, res)
+	if quotas := c.quotaStats(); len(quotas) > 0 {
+		stats["quotas"] = quotas
+	}
+	if throttled := c.limits.throttled(); len(throttled) > 0 {
+		stats["throttled"] = throttled
+	}
+	c.events.stats(stats)
 
 	return stats
 }
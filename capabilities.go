@@ -0,0 +1,47 @@
+package LCache_go
+
+import (
+	"fmt"
+	"lcache/store"
+)
+
+// Capabilities returns what the cache's store supports, see store.CapabilitiesOf.
+// A closed cache reports none.
+func (c *Cache) Capabilities() store.Capabilities {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.store == nil {
+		return store.Capabilities{}
+	}
+	caps := store.CapabilitiesOf(c.store)
+	if c.cipher != nil {
+		// sealed values are bound to their key, see Rename
+		caps.Renaming = false
+	}
+	return caps
+}
+
+// checkCapabilities rejects options that would be silently ignored by a store
+// with caps
+func (o CacheOptions) checkCapabilities(caps store.Capabilities) error {
+	if !caps.TTL {
+		if o.DefaultTTL > 0 {
+			return fmt.Errorf("%w: DefaultTTL needs a store with TTL support", ErrNotSupported)
+		}
+		for _, r := range o.Rules {
+			if r.TTL > 0 || r.MaxTTL > 0 {
+				return fmt.Errorf("%w: rule %q sets a TTL, which needs a store with TTL support", ErrNotSupported, r.Prefix+r.Pattern)
+			}
+		}
+	}
+	if o.OnEvicted != nil && !caps.Events {
+		return fmt.Errorf("%w: OnEvicted needs a store that reports events", ErrNotSupported)
+	}
+	if o.OnExpired != nil && !caps.Events {
+		return fmt.Errorf("%w: OnExpired needs a store that reports events", ErrNotSupported)
+	}
+	if o.SnapshotPath != "" && !caps.Iteration {
+		return fmt.Errorf("%w: SnapshotPath needs a store that supports iteration", ErrNotSupported)
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+package LCache_go
+
+import (
+	"lcache/store"
+	"runtime"
+	"time"
+)
+
+// clearBatch bounds how many deletes ClearFunc makes per hold of the cache lock
+const clearBatch = 256
+
+// ClearFunc removes every entry for which fn returns true and returns how many
+// were removed, e.g. entries older than a cutoff, larger than a size or whose key
+// matches a regexp. Keys are as stored, like Scan. The store is walked once to
+// copy the metadata, fn runs outside any lock and the deletes are made in small
+// batches, so writers are never blocked for long. Each match is checked again
+// against fresh metadata right before it is deleted, so fn may be called more
+// than once per key and must not have side effects.
+func (c *Cache) ClearFunc(fn func(key string, meta EntryInfo) bool) int {
+	var infos []EntryInfo
+	c.rangeEntries(func(key string, value store.Value, expiresAt time.Time) bool {
+		infos = append(infos, newEntryInfo(key, value, expiresAt))
+		return true
+	})
+
+	var keys []string
+	for _, info := range infos {
+		if fn(info.Key, info) {
+			keys = append(keys, info.Key)
+		}
+	}
+	removed := c.removeStored(keys, fn)
+	c.logger.Info("Entries cleared by predicate", "matched", len(keys), "removed", removed)
+	return removed
+}
+
+// removeStored deletes store keys, bypassing KeyTransform and FingerprintKeys,
+// in batches of clearBatch. A non-nil recheck must still hold for an entry's
+// current metadata for it to be deleted.
+func (c *Cache) removeStored(keys []string, recheck func(key string, meta EntryInfo) bool) int {
+	removed := 0
+	for len(keys) > 0 {
+		n := len(keys)
+		if n > clearBatch {
+			n = clearBatch
+		}
+		if !c.removeStoredBatch(keys[:n], recheck, &removed) {
+			break
+		}
+		keys = keys[n:]
+		runtime.Gosched()
+	}
+	return removed
+}
+
+// removeStoredBatch reports false once the cache is closed
+func (c *Cache) removeStoredBatch(keys []string, recheck func(key string, meta EntryInfo) bool, removed *int) bool {
+	if !OpenedAndInitialized(c) {
+		return false
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.store == nil {
+		return false
+	}
+	for _, key := range keys {
+		if recheck != nil {
+			value, expiresAt, ok := c.store.Peek(key)
+			if !ok || !recheck(key, newEntryInfo(key, value, expiresAt)) {
+				continue
+			}
+		}
+		if c.shadow != nil {
+			c.shadow.delete(key)
+		}
+		if c.store.Delete(key) {
+			*removed++
+		}
+	}
+	return true
+}
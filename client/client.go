@@ -0,0 +1,235 @@
+// Package client is a thin Go client for standalone LCache servers speaking the
+// httpapi protocol. It pools connections per node, retries failed attempts,
+// bounds every attempt with a timeout and spreads keys over several servers.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+var (
+	ErrNotFound = errors.New("lcache/client: key not found")
+	ErrNoNodes  = errors.New("lcache/client: no nodes")
+)
+
+// ttlHeader matches httpapi.TTLHeader, kept separate so the client doesn't
+// pull in the cache itself
+const ttlHeader = "X-Cache-TTL"
+
+// StatusError is an error answer from a server
+type StatusError struct {
+	Node    string
+	Code    int
+	Message string
+}
+
+func (e *StatusError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("lcache/client: %s: %s", e.Node, http.StatusText(e.Code))
+	}
+	return fmt.Sprintf("lcache/client: %s: %s", e.Node, e.Message)
+}
+
+type Options struct {
+	Nodes        []string      // Server addresses, host:port or base URLs; keys are spread over them
+	Token        string        // Sent as a bearer token when set
+	Timeout      time.Duration // Bound for a single attempt, defaults to 2s
+	Retries      int           // Extra attempts after a network error or 5xx answer
+	RetryBackoff time.Duration // Wait before the first retry, doubled for each further one; defaults to 50ms
+	MaxIdleConns int           // Idle connections kept per node, defaults to 16
+
+	// KeyTransform maps every key before it is routed and sent, e.g. to match
+	// the normalization the servers' caches apply
+	KeyTransform func(key string) string
+
+	// Transport replaces the pooled HTTP transport, e.g. with one injecting
+	// latency or failures in tests; MaxIdleConns doesn't apply to it
+	Transport http.RoundTripper
+}
+
+func DefaultOptions() Options {
+	return Options{
+		Timeout:      2 * time.Second,
+		Retries:      2,
+		RetryBackoff: 50 * time.Millisecond,
+		MaxIdleConns: 16,
+	}
+}
+
+type Client struct {
+	opts  Options
+	nodes []string
+	http  *http.Client
+}
+
+func New(opts Options) (*Client, error) {
+	if len(opts.Nodes) == 0 {
+		return nil, ErrNoNodes
+	}
+	if opts.Timeout < 0 || opts.Retries < 0 || opts.RetryBackoff < 0 || opts.MaxIdleConns < 0 {
+		return nil, errors.New("lcache/client: options must not be negative")
+	}
+	defaults := DefaultOptions()
+	if opts.Timeout == 0 {
+		opts.Timeout = defaults.Timeout
+	}
+	if opts.RetryBackoff == 0 {
+		opts.RetryBackoff = defaults.RetryBackoff
+	}
+	if opts.MaxIdleConns == 0 {
+		opts.MaxIdleConns = defaults.MaxIdleConns
+	}
+
+	nodes := make([]string, len(opts.Nodes))
+	for i, node := range opts.Nodes {
+		if !strings.Contains(node, "://") {
+			node = "http://" + node
+		}
+		nodes[i] = strings.TrimSuffix(node, "/")
+	}
+	transport := opts.Transport
+	if transport == nil {
+		pooled := http.DefaultTransport.(*http.Transport).Clone()
+		pooled.MaxIdleConnsPerHost = opts.MaxIdleConns
+		transport = pooled
+	}
+	return &Client{
+		opts:  opts,
+		nodes: nodes,
+		http:  &http.Client{Transport: transport},
+	}, nil
+}
+
+// Get returns the value of key, ErrNotFound on a miss
+func (c *Client) Get(ctx context.Context, key string) ([]byte, error) {
+	return c.do(ctx, http.MethodGet, key, nil, nil)
+}
+
+// Set stores value under key, a zero ttl leaves the server's default
+func (c *Client) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	header := http.Header{}
+	if ttl > 0 {
+		header.Set(ttlHeader, ttl.String())
+	}
+	_, err := c.do(ctx, http.MethodPut, key, value, header)
+	return err
+}
+
+// Delete removes key, ErrNotFound if it wasn't cached
+func (c *Client) Delete(ctx context.Context, key string) error {
+	_, err := c.do(ctx, http.MethodDelete, key, nil, nil)
+	return err
+}
+
+// Node returns the server responsible for key. Keys are placed with rendezvous
+// hashing, so adding or removing a node only moves the keys it gains or loses.
+func (c *Client) Node(key string) string {
+	if c.opts.KeyTransform != nil {
+		key = c.opts.KeyTransform(key)
+	}
+	return c.node(key)
+}
+
+func (c *Client) node(key string) string {
+	best, bestScore := c.nodes[0], uint64(0)
+	for _, node := range c.nodes {
+		h := fnv.New64a()
+		h.Write([]byte(node))
+		h.Write([]byte{0})
+		h.Write([]byte(key))
+		if score := mix(h.Sum64()); score >= bestScore {
+			best, bestScore = node, score
+		}
+	}
+	return best
+}
+
+// mix spreads the low bits fnv leaves poorly mixed across the whole word (murmur3 fmix64)
+func mix(h uint64) uint64 {
+	h ^= h >> 33
+	h *= 0xff51afd7ed558ccd
+	h ^= h >> 33
+	h *= 0xc4ceb9fe1a85ec53
+	h ^= h >> 33
+	return h
+}
+
+// Close drops the pooled connections
+func (c *Client) Close() {
+	c.http.CloseIdleConnections()
+}
+
+func (c *Client) do(ctx context.Context, method, key string, body []byte, header http.Header) ([]byte, error) {
+	if c.opts.KeyTransform != nil {
+		key = c.opts.KeyTransform(key)
+	}
+	node := c.node(key)
+	backoff := c.opts.RetryBackoff
+	var err error
+	for attempt := 0; ; attempt++ {
+		var data []byte
+		var retry bool
+		data, retry, err = c.attempt(ctx, node, method, key, body, header)
+		if err == nil || !retry || attempt == c.opts.Retries || ctx.Err() != nil {
+			return data, err
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+// attempt makes one request, reporting whether a failure is worth retrying
+func (c *Client) attempt(ctx context.Context, node, method, key string, body []byte, header http.Header) ([]byte, bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.opts.Timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, method, node+"/cache/"+url.PathEscape(key), bytes.NewReader(body))
+	if err != nil {
+		return nil, false, err
+	}
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	if c.opts.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.opts.Token)
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		// network errors and attempt timeouts are retried, do stops once the caller's ctx is done
+		return nil, true, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, true, err
+	}
+	switch {
+	case resp.StatusCode == http.StatusOK || resp.StatusCode == http.StatusNoContent:
+		return data, false, nil
+	case resp.StatusCode == http.StatusNotFound:
+		return nil, false, ErrNotFound
+	}
+	serr := &StatusError{Node: node, Code: resp.StatusCode}
+	var e struct {
+		Error string `json:"error"`
+	}
+	if json.Unmarshal(data, &e) == nil {
+		serr.Message = e.Error
+	}
+	return nil, resp.StatusCode >= 500, serr
+}
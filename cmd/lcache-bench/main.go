@@ -0,0 +1,63 @@
+// Command lcache-bench runs a generated workload against bare stores and a
+// full cache and prints throughput and latency, see package bench.
+//
+//	lcache-bench -zipf 1.1 -reads 0.95 -workers 8 -max-bytes 64MB
+package main
+
+import (
+	"flag"
+	lcache "lcache"
+	"lcache/bench"
+	"lcache/store"
+	"log"
+	"os"
+	"strings"
+)
+
+func main() {
+	keys := flag.Int("keys", 100000, "distinct keys")
+	zipf := flag.Float64("zipf", 1.01, "Zipf skew of key popularity, > 1; 0 for uniform")
+	reads := flag.Float64("reads", 0.9, "fraction of reads, negative for write-only")
+	valueMin := flag.Int("value-min", 128, "smallest value size in bytes")
+	valueMax := flag.Int("value-max", 128, "largest value size in bytes, above value-min draws sizes uniformly")
+	ops := flag.Int("ops", 1000000, "operations per target")
+	workers := flag.Int("workers", 1, "concurrent goroutines")
+	maxBytes := flag.Int64("max-bytes", 64<<20, "MaxBytes of every target")
+	types := flag.String("types", string(store.LRU), "comma separated store types to benchmark")
+	prefill := flag.Bool("prefill", true, "write every key once before measuring")
+	seed := flag.Int64("seed", 1, "random seed")
+	flag.Parse()
+	log.SetPrefix("lcache-bench: ")
+	log.SetFlags(0)
+
+	w := bench.Workload{
+		Keys:      *keys,
+		Zipf:      *zipf,
+		ReadRatio: *reads,
+		ValueSize: bench.Fixed(*valueMin),
+		Ops:       *ops,
+		Workers:   *workers,
+		Prefill:   *prefill,
+		Seed:      *seed,
+	}
+	if *valueMax > *valueMin {
+		w.ValueSize = bench.Uniform{Min: *valueMin, Max: *valueMax}
+	}
+
+	var targets []bench.Target
+	for _, t := range strings.Split(*types, ",") {
+		targets = append(targets, bench.StoreTarget(store.CacheType(t), store.Options{MaxBytes: *maxBytes}))
+	}
+	opts := lcache.DefaultCacheOptions()
+	opts.MaxBytes = *maxBytes
+	opts.Logger = nil
+	targets = append(targets, bench.CacheTarget(opts))
+
+	reports, err := bench.Run(w, targets...)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := bench.WriteTable(os.Stdout, reports); err != nil {
+		log.Fatal(err)
+	}
+}
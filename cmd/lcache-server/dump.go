@@ -0,0 +1,26 @@
+//go:build !windows
+
+package main
+
+import (
+	lcache "lcache"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// dumpOnSignal writes the diagnostic report on SIGUSR1, and on SIGUSR2 first
+// writes a snapshot to snapshot_path, until stop is closed
+func dumpOnSignal(cache *lcache.Cache, dumpDir string, stop <-chan struct{}) {
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, syscall.SIGUSR1, syscall.SIGUSR2)
+	defer signal.Stop(sig)
+	for {
+		select {
+		case s := <-sig:
+			dump(cache, dumpDir, s == syscall.SIGUSR2)
+		case <-stop:
+			return
+		}
+	}
+}
@@ -0,0 +1,6 @@
+package main
+
+import lcache "lcache"
+
+// dumpOnSignal does nothing, Windows has no SIGUSR1 or SIGUSR2
+func dumpOnSignal(cache *lcache.Cache, dumpDir string, stop <-chan struct{}) {}
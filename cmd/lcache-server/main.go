@@ -0,0 +1,239 @@
+// Command lcache-server runs a cache as a standalone service. It serves the
+// cache over RESP, the memcached text protocol and HTTP, where /cache/ is the
+// httpapi REST API and /admin/ the admin API with stats and health probes.
+//
+// Cache settings come from -config, a JSON or YAML file, overridden by LCACHE_*
+// environment variables (see lcache.ApplyEnv). Set snapshot_path to persist the
+// cache across restarts.
+//
+// On SIGTERM or SIGINT the server shuts down gracefully: /admin/readyz starts
+// failing, after -drain-delay the listeners close, in-progress commands and
+// requests finish, and the cache writes its final snapshot, all within -grace.
+// Whatever is left when the grace period ends is dropped; a second signal
+// exits right away. Peer clustering isn't available, every server is a
+// single node; spread keys over several with the client package.
+//
+// The memcached protocol has no authentication, so with -token or -read-token
+// set the server refuses to start it unless -memcached-insecure is given.
+//
+// SIGUSR1 dumps the diagnostic report (see lcache.Cache.Report) to stderr, or
+// to a timestamped file in -dump-dir; SIGUSR2 writes a snapshot to
+// snapshot_path first and then dumps the report.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	lcache "lcache"
+	"lcache/admin"
+	"lcache/auth"
+	"lcache/httpapi"
+	"lcache/server"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"time"
+)
+
+func main() {
+	configPath := flag.String("config", "", "JSON or YAML cache config, defaults apply when empty")
+	respAddr := flag.String("resp", ":6379", "RESP listen address, empty disables")
+	memcachedAddr := flag.String("memcached", "", "memcached listen address, empty disables")
+	memcachedInsecure := flag.Bool("memcached-insecure", false, "serve memcached even with -token or -read-token set; it has no authentication")
+	httpAddr := flag.String("http", ":8080", "HTTP listen address for /cache/ and /admin/, empty disables")
+	token := flag.String("token", os.Getenv("LCACHE_TOKEN"), "read-write bearer token, defaults to $LCACHE_TOKEN; empty leaves the server open")
+	readToken := flag.String("read-token", os.Getenv("LCACHE_READ_TOKEN"), "read-only bearer token, defaults to $LCACHE_READ_TOKEN")
+	notify := flag.String("notify-keyspace-events", "", "Redis-style keyspace notification classes for RESP, e.g. \"Ex\"; empty disables")
+	grace := flag.Duration("grace", 25*time.Second, "how long a graceful shutdown may take")
+	drainDelay := flag.Duration("drain-delay", 0, "how long to keep serving after readiness fails, e.g. while a load balancer catches up")
+	dumpDir := flag.String("dump-dir", "", "directory for reports dumped on SIGUSR1/SIGUSR2, empty writes them to stderr")
+	flag.Parse()
+
+	log.SetPrefix("lcache-server: ")
+	err := run(*configPath, *respAddr, *memcachedAddr, *httpAddr, *token, *readToken, *notify, *dumpDir, *memcachedInsecure, *grace, *drainDelay)
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+// service is what shutdown needs from the protocol servers and http.Server
+type service interface {
+	Shutdown(ctx context.Context) error
+	Close() error
+}
+
+func run(configPath, respAddr, memcachedAddr, httpAddr, token, readToken, notify, dumpDir string, memcachedInsecure bool, grace, drainDelay time.Duration) error {
+	opts, err := loadOptions(configPath)
+	if err != nil {
+		return err
+	}
+	cache, err := lcache.NewCache(opts)
+	if err != nil {
+		return err
+	}
+
+	var authn *auth.Authenticator
+	if token != "" || readToken != "" {
+		authn = auth.New()
+		if token != "" {
+			authn.AddToken(token, auth.RoleWrite)
+		}
+		if readToken != "" {
+			authn.AddToken(readToken, auth.RoleRead)
+		}
+	}
+
+	var servers []service
+	errc := make(chan error, 3)
+	serve := func(name, addr string, fn func(net.Listener) error) error {
+		l, err := net.Listen("tcp", addr)
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		log.Printf("serving %s on %s", name, l.Addr())
+		go func() { errc <- fmt.Errorf("%s: %w", name, fn(l)) }()
+		return nil
+	}
+
+	if respAddr != "" {
+		s := server.NewRESP(cache).WithAuth(authn)
+		if err := s.SetKeyspaceEvents(notify); err != nil {
+			return fmt.Errorf("notify-keyspace-events: %w", err)
+		}
+		servers = append(servers, s)
+		if err := serve("resp", respAddr, s.Serve); err != nil {
+			return err
+		}
+	}
+	if memcachedAddr != "" {
+		if authn != nil {
+			if !memcachedInsecure {
+				return fmt.Errorf("memcached has no authentication, refusing to serve it with tokens set; pass -memcached-insecure to serve it anyway")
+			}
+			log.Printf("memcached has no authentication, serving it without")
+		}
+		s := server.NewMemcached(cache)
+		servers = append(servers, s)
+		if err := serve("memcached", memcachedAddr, s.Serve); err != nil {
+			return err
+		}
+	}
+	if httpAddr != "" {
+		mux := http.NewServeMux()
+		mux.Handle("/cache/", httpapi.NewHandler(cache).WithAuth(authn))
+		mux.Handle("/admin/", http.StripPrefix("/admin", admin.NewHandler(cache).WithAuth(authn)))
+		s := &http.Server{Handler: mux}
+		servers = append(servers, s)
+		if err := serve("http", httpAddr, s.Serve); err != nil {
+			return err
+		}
+	}
+	if len(servers) == 0 {
+		return fmt.Errorf("no protocol enabled")
+	}
+
+	stopDump := make(chan struct{})
+	go dumpOnSignal(cache, dumpDir, stopDump)
+
+	sig := make(chan os.Signal, 2)
+	signal.Notify(sig, syscall.SIGINT, syscall.SIGTERM)
+	select {
+	case s := <-sig:
+		log.Printf("received %v, shutting down within %v", s, grace)
+		err = nil
+	case err = <-errc:
+	}
+	close(stopDump)
+	if serr := shutdown(cache, servers, sig, grace, drainDelay); err == nil {
+		err = serr
+	}
+	return err
+}
+
+// shutdown drains the servers and closes the cache within grace, giving up on
+// anything left when it runs out or another signal arrives
+func shutdown(cache *lcache.Cache, servers []service, sig <-chan os.Signal, grace, drainDelay time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), grace)
+	defer cancel()
+	go func() {
+		select {
+		case s := <-sig:
+			log.Printf("received %v again, exiting now", s)
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	cache.MarkDraining()
+	if drainDelay > 0 {
+		select {
+		case <-time.After(drainDelay):
+		case <-ctx.Done():
+		}
+	}
+
+	var wg sync.WaitGroup
+	for _, s := range servers {
+		wg.Add(1)
+		go func(s service) {
+			defer wg.Done()
+			if err := s.Shutdown(ctx); err != nil {
+				s.Close()
+			}
+		}(s)
+	}
+	wg.Wait()
+
+	// CloseContext writes the final snapshot even when ctx has run out
+	err := cache.CloseContext(ctx)
+	if err != nil {
+		log.Printf("shutdown incomplete: %v", err)
+	} else {
+		log.Printf("shutdown complete")
+	}
+	return err
+}
+
+// dump writes the report to stderr or a new file in dumpDir, after writing a
+// snapshot if asked to
+func dump(cache *lcache.Cache, dumpDir string, snapshot bool) {
+	if snapshot {
+		if err := cache.WriteSnapshot(); err != nil {
+			log.Printf("snapshot: %v", err)
+		} else {
+			log.Printf("snapshot written")
+		}
+	}
+	report := cache.Report()
+	if dumpDir == "" {
+		fmt.Fprint(os.Stderr, report)
+		return
+	}
+	path := filepath.Join(dumpDir, "lcache-report-"+time.Now().Format("20060102-150405.000")+".txt")
+	if err := os.WriteFile(path, []byte(report), 0o644); err != nil {
+		log.Printf("report: %v", err)
+		return
+	}
+	log.Printf("report written to %s", path)
+}
+
+// loadOptions reads configPath when set and applies the environment on top
+func loadOptions(configPath string) (lcache.CacheOptions, error) {
+	opts := lcache.DefaultCacheOptions()
+	if configPath != "" {
+		var err error
+		if opts, err = lcache.LoadConfig(configPath); err != nil {
+			return opts, err
+		}
+	}
+	if err := lcache.ApplyEnv("", &opts); err != nil {
+		return opts, err
+	}
+	return opts, opts.Validate()
+}
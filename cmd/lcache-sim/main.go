@@ -0,0 +1,84 @@
+// Command lcache-sim replays an access trace against simulated caches of
+// several sizes and prints the hit rate curve as CSV, see package simulate.
+//
+//	lcache-sim -format twitter -sizes 64MB,256MB,1GB cluster52.csv
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"lcache/simulate"
+	"lcache/store"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+)
+
+func main() {
+	format := flag.String("format", simulate.FormatCSV, "trace format: csv, arc or twitter")
+	cacheType := flag.String("type", string(store.LRU), "cache type to simulate")
+	sizes := flag.String("sizes", "1MB,8MB,64MB", "comma separated cache sizes, with an optional KB, MB or GB suffix")
+	blockSize := flag.Int("block-size", 1, "bytes per block of arc traces")
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: lcache-sim [flags] [trace]\n\nreads the trace from stdin when no file is given\n\nflags:\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	log.SetPrefix("lcache-sim: ")
+	log.SetFlags(0)
+
+	var err error
+	var in io.Reader = os.Stdin
+	if flag.NArg() > 0 {
+		f, err := os.Open(flag.Arg(0))
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer f.Close()
+		in = f
+	}
+	var trace simulate.Reader
+	if *format == simulate.FormatARC {
+		trace = simulate.NewARCReader(in, *blockSize)
+	} else if trace, err = simulate.NewReader(*format, in); err != nil {
+		log.Fatal(err)
+	}
+	opts := simulate.Options{CacheType: store.CacheType(*cacheType)}
+	for _, s := range strings.Split(*sizes, ",") {
+		n, err := parseSize(strings.TrimSpace(s))
+		if err != nil {
+			log.Fatal(err)
+		}
+		opts.Sizes = append(opts.Sizes, n)
+	}
+
+	results, err := simulate.Run(trace, opts)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := simulate.WriteCSV(os.Stdout, results); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// parseSize reads a byte count like 4096, 512KB or 2GB
+func parseSize(s string) (int64, error) {
+	mult, digits := int64(1), s
+	upper := strings.ToUpper(s)
+	for _, u := range []struct {
+		suffix string
+		mult   int64
+	}{{"KB", 1 << 10}, {"MB", 1 << 20}, {"GB", 1 << 30}} {
+		if strings.HasSuffix(upper, u.suffix) {
+			mult, digits = u.mult, s[:len(s)-len(u.suffix)]
+			break
+		}
+	}
+	n, err := strconv.ParseInt(digits, 10, 64)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("invalid size %q", s)
+	}
+	return n * mult, nil
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// adminClient talks to an admin.Handler over HTTP
+
+type adminClient struct {
+	base  string
+	token string
+	http  *http.Client
+}
+
+func newAdminClient(addr, token string, timeout time.Duration) *adminClient {
+	if !strings.Contains(addr, "://") {
+		addr = "http://" + addr
+	}
+	return &adminClient{
+		base:  strings.TrimSuffix(addr, "/"),
+		token: token,
+		http:  &http.Client{Timeout: timeout},
+	}
+}
+
+type keyInfo struct {
+	Key        string    `json:"key"`
+	Size       int       `json:"size"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	TTLSeconds float64   `json:"ttl_seconds"`
+	Value      []byte    `json:"value"`
+}
+
+func (c *adminClient) get(key string) (keyInfo, error) {
+	var info keyInfo
+	err := c.do(http.MethodGet, "/keys/"+url.PathEscape(key)+"?value=true", nil, &info)
+	return info, err
+}
+
+type keysPage struct {
+	Keys   []keyInfo `json:"keys"`
+	Cursor string    `json:"cursor"`
+}
+
+func (c *adminClient) keys(prefix, cursor string, limit int) (keysPage, error) {
+	var page keysPage
+	path := fmt.Sprintf("/keys?prefix=%s&cursor=%s&limit=%d", url.QueryEscape(prefix), url.QueryEscape(cursor), limit)
+	err := c.do(http.MethodGet, path, nil, &page)
+	return page, err
+}
+
+func (c *adminClient) set(key string, value []byte, ttl time.Duration) error {
+	path := "/keys/" + url.PathEscape(key)
+	if ttl > 0 {
+		path += "?ttl=" + url.QueryEscape(ttl.String())
+	}
+	return c.do(http.MethodPut, path, value, nil)
+}
+
+func (c *adminClient) delete(key string) error {
+	return c.do(http.MethodDelete, "/keys/"+url.PathEscape(key), nil, nil)
+}
+
+func (c *adminClient) stats() (map[string]interface{}, error) {
+	var stats map[string]interface{}
+	err := c.do(http.MethodGet, "/stats", nil, &stats)
+	return stats, err
+}
+
+func (c *adminClient) snapshot() error {
+	return c.do(http.MethodPost, "/snapshot", nil, nil)
+}
+
+func (c *adminClient) flush() error {
+	return c.do(http.MethodPost, "/flush", nil, nil)
+}
+
+type flushResult struct {
+	Matched int `json:"matched"`
+	Deleted int `json:"deleted"`
+}
+
+func (c *adminClient) flushPattern(pattern string, dryRun bool) (flushResult, error) {
+	var res flushResult
+	path := fmt.Sprintf("/flush?pattern=%s&dry_run=%t", url.QueryEscape(pattern), dryRun)
+	err := c.do(http.MethodPost, path, nil, &res)
+	return res, err
+}
+
+func (c *adminClient) do(method, path string, body []byte, out interface{}) error {
+	req, err := http.NewRequest(method, c.base+path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		var e struct {
+			Error string `json:"error"`
+		}
+		if json.Unmarshal(data, &e) == nil && e.Error != "" {
+			return fmt.Errorf("%s: %s", resp.Status, e.Error)
+		}
+		return fmt.Errorf("%s", resp.Status)
+	}
+	if out != nil {
+		return json.Unmarshal(data, out)
+	}
+	return nil
+}
@@ -0,0 +1,207 @@
+// Command lcachectl inspects and operates a running cache through its admin API.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+)
+
+const usage = `usage: lcachectl [flags] <command> [args]
+
+commands:
+  get <key>                   print a value and its metadata
+  set <key> <value> [ttl]     store a value, ttl like 30s or 5m
+  delete <key>                delete a key
+  keys [prefix]               list keys with their size and expiry
+  stats                       print cache statistics
+  snapshot                    write a snapshot on the server
+  flush [pattern] [-n]        clear the cache, or only keys matching a glob;
+                              -n counts the matches without deleting
+  watch [interval]            print hit rate and memory usage live
+  shell                       start an interactive session
+
+flags:
+`
+
+func main() {
+	addr := flag.String("addr", "localhost:8080/admin", "admin API address")
+	timeout := flag.Duration("timeout", 5*time.Second, "request timeout")
+	token := flag.String("token", os.Getenv("LCACHE_TOKEN"), "bearer token for the admin API, defaults to $LCACHE_TOKEN")
+	flag.Usage = func() {
+		fmt.Fprint(os.Stderr, usage)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	c := newAdminClient(*addr, *token, *timeout)
+	if err := run(c, flag.Args()); err != nil {
+		fmt.Fprintln(os.Stderr, "lcachectl:", err)
+		os.Exit(1)
+	}
+}
+
+func run(c *adminClient, args []string) error {
+	cmd, args := args[0], args[1:]
+	switch cmd {
+	case "get":
+		if len(args) != 1 {
+			return fmt.Errorf("usage: get <key>")
+		}
+		info, err := c.get(args[0])
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%s\n(%d bytes", info.Value, info.Size)
+		if info.TTLSeconds > 0 {
+			fmt.Printf(", ttl %s", time.Duration(info.TTLSeconds*float64(time.Second)).Round(time.Second))
+		}
+		fmt.Println(")")
+	case "set":
+		if len(args) < 2 || len(args) > 3 {
+			return fmt.Errorf("usage: set <key> <value> [ttl]")
+		}
+		var ttl time.Duration
+		if len(args) == 3 {
+			var err error
+			if ttl, err = time.ParseDuration(args[2]); err != nil {
+				return err
+			}
+		}
+		if err := c.set(args[0], []byte(args[1]), ttl); err != nil {
+			return err
+		}
+		fmt.Println("OK")
+	case "delete", "del":
+		if len(args) != 1 {
+			return fmt.Errorf("usage: delete <key>")
+		}
+		if err := c.delete(args[0]); err != nil {
+			return err
+		}
+		fmt.Println("OK")
+	case "keys":
+		if len(args) > 1 {
+			return fmt.Errorf("usage: keys [prefix]")
+		}
+		prefix := ""
+		if len(args) == 1 {
+			prefix = args[0]
+		}
+		return listKeys(c, prefix)
+	case "stats":
+		stats, err := c.stats()
+		if err != nil {
+			return err
+		}
+		printStats(stats)
+	case "snapshot":
+		if err := c.snapshot(); err != nil {
+			return err
+		}
+		fmt.Println("OK")
+	case "flush":
+		dryRun := len(args) > 0 && args[len(args)-1] == "-n"
+		if dryRun {
+			args = args[:len(args)-1]
+		}
+		switch {
+		case len(args) > 1 || (dryRun && len(args) == 0):
+			return fmt.Errorf("usage: flush [pattern] [-n]")
+		case len(args) == 1:
+			res, err := c.flushPattern(args[0], dryRun)
+			if err != nil {
+				return err
+			}
+			if dryRun {
+				fmt.Printf("%d keys match\n", res.Matched)
+			} else {
+				fmt.Printf("deleted %d keys\n", res.Deleted)
+			}
+		default:
+			if err := c.flush(); err != nil {
+				return err
+			}
+			fmt.Println("OK")
+		}
+	case "shell":
+		return shell(c, os.Stdin, os.Stdout)
+	case "watch":
+		interval := 2 * time.Second
+		if len(args) == 1 {
+			var err error
+			if interval, err = time.ParseDuration(args[0]); err != nil {
+				return err
+			}
+		}
+		return watch(c, interval)
+	default:
+		return fmt.Errorf("unknown command %q", cmd)
+	}
+	return nil
+}
+
+// listKeys prints every key under prefix, a page at a time
+func listKeys(c *adminClient, prefix string) error {
+	cursor := "0"
+	for {
+		page, err := c.keys(prefix, cursor, 500)
+		if err != nil {
+			return err
+		}
+		for _, k := range page.Keys {
+			if k.ExpiresAt.IsZero() {
+				fmt.Printf("%-40s %10d\n", k.Key, k.Size)
+			} else {
+				fmt.Printf("%-40s %10d  expires %s\n", k.Key, k.Size, k.ExpiresAt.Local().Format(time.RFC3339))
+			}
+		}
+		if page.Cursor == "0" || page.Cursor == "" {
+			return nil
+		}
+		cursor = page.Cursor
+	}
+}
+
+func printStats(stats map[string]interface{}) {
+	keys := make([]string, 0, len(stats))
+	for k := range stats {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		switch v := stats[k].(type) {
+		case map[string]interface{}, []interface{}:
+			b, _ := json.Marshal(v)
+			fmt.Printf("%-24s %s\n", k, b)
+		default:
+			fmt.Printf("%-24s %v\n", k, v)
+		}
+	}
+}
+
+func watch(c *adminClient, interval time.Duration) error {
+	fmt.Printf("%-10s %10s %10s %10s %14s\n", "time", "hit_1m", "hit_5m", "entries", "used_bytes")
+	for {
+		stats, err := c.stats()
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%-10s %9.1f%% %9.1f%% %10v %14v\n", time.Now().Format("15:04:05"),
+			100*number(stats["hit_rate_1m"]), 100*number(stats["hit_rate_5m"]),
+			stats["size"], stats["used_bytes"])
+		time.Sleep(interval)
+	}
+}
+
+func number(v interface{}) float64 {
+	f, _ := v.(float64)
+	return f
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+var commands = []string{"get", "set", "delete", "keys", "ttl", "stats", "snapshot", "flush", "watch", "help", "exit"}
+
+const shellHelp = `commands: get, set, delete, keys, ttl, stats, snapshot, flush, watch, help, exit
+  unique prefixes are accepted ("st" runs stats), and a word ending in "?"
+  lists its completions ("s?" prints set, snapshot, stats)`
+
+// shell runs an interactive session reading commands from in
+func shell(c *adminClient, in io.Reader, out io.Writer) error {
+	fmt.Fprintln(out, `lcachectl shell, type "help" for commands`)
+	scanner := bufio.NewScanner(in)
+	for {
+		fmt.Fprint(out, "lcache> ")
+		if !scanner.Scan() {
+			fmt.Fprintln(out)
+			return scanner.Err()
+		}
+		args, err := splitArgs(scanner.Text())
+		if err != nil {
+			fmt.Fprintln(out, "error:", err)
+			continue
+		}
+		if len(args) == 0 {
+			continue
+		}
+
+		if word := args[len(args)-1]; strings.HasSuffix(word, "?") {
+			if len(args) == 1 {
+				fmt.Fprintln(out, strings.Join(complete(strings.TrimSuffix(word, "?")), "  "))
+			}
+			continue
+		}
+
+		cmd, err := resolve(args[0])
+		if err != nil {
+			fmt.Fprintln(out, "error:", err)
+			continue
+		}
+		switch cmd {
+		case "exit":
+			return nil
+		case "help":
+			fmt.Fprintln(out, shellHelp)
+		case "ttl":
+			if len(args) != 2 {
+				fmt.Fprintln(out, "usage: ttl <key>")
+				continue
+			}
+			info, err := c.get(args[1])
+			if err != nil {
+				fmt.Fprintln(out, "error:", err)
+			} else if info.TTLSeconds > 0 {
+				fmt.Fprintf(out, "%.0fs\n", info.TTLSeconds)
+			} else {
+				fmt.Fprintln(out, "no expiration")
+			}
+		default:
+			if err := run(c, append([]string{cmd}, args[1:]...)); err != nil {
+				fmt.Fprintln(out, "error:", err)
+			}
+		}
+	}
+}
+
+// complete returns the commands starting with prefix
+func complete(prefix string) []string {
+	var matches []string
+	for _, cmd := range commands {
+		if strings.HasPrefix(cmd, prefix) {
+			matches = append(matches, cmd)
+		}
+	}
+	sort.Strings(matches)
+	return matches
+}
+
+// resolve expands a unique command prefix
+func resolve(word string) (string, error) {
+	matches := complete(word)
+	for _, m := range matches {
+		if m == word {
+			return m, nil
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return "", fmt.Errorf("unknown command %q", word)
+	case 1:
+		return matches[0], nil
+	default:
+		return "", fmt.Errorf("ambiguous command %q: %s", word, strings.Join(matches, ", "))
+	}
+}
+
+// splitArgs splits a line on spaces, honoring single and double quotes
+func splitArgs(line string) ([]string, error) {
+	var args []string
+	var cur strings.Builder
+	var quote rune
+	inWord := false
+	for _, r := range line {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				cur.WriteRune(r)
+			}
+		case r == '"' || r == '\'':
+			quote, inWord = r, true
+		case r == ' ' || r == '\t':
+			if inWord {
+				args = append(args, cur.String())
+				cur.Reset()
+				inWord = false
+			}
+		default:
+			cur.WriteRune(r)
+			inWord = true
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated quote")
+	}
+	if inWord {
+		args = append(args, cur.String())
+	}
+	return args, nil
+}
@@ -0,0 +1,249 @@
+package LCache_go
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"lcache/store"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Config is the declarative form of CacheOptions read by LoadConfig. Durations use
+// time.ParseDuration syntax ("30s", "5m"); unset fields keep their defaults.
+
+type Config struct {
+	Name            string            `json:"name" yaml:"name"`
+	Labels          map[string]string `json:"labels" yaml:"labels"`
+	CacheType       string            `json:"cache_type" yaml:"cache_type"`
+	MaxBytes        *int64            `json:"max_bytes" yaml:"max_bytes"`
+	MaxEntryBytes   *int64            `json:"max_entry_bytes" yaml:"max_entry_bytes"`
+	CleanupInterval string            `json:"cleanup_interval" yaml:"cleanup_interval"`
+	EvictionBatch   *int64            `json:"eviction_batch" yaml:"eviction_batch"`
+	AsyncEviction   *bool             `json:"async_eviction" yaml:"async_eviction"`
+	AdaptiveCleanup *bool             `json:"adaptive_cleanup" yaml:"adaptive_cleanup"`
+	FingerprintKeys *bool             `json:"fingerprint_keys" yaml:"fingerprint_keys"`
+	VerifyChecksums *bool             `json:"verify_checksums" yaml:"verify_checksums"`
+	TrackWriters    *bool             `json:"track_writers" yaml:"track_writers"`
+	DefaultTTL      string            `json:"default_ttl" yaml:"default_ttl"`
+	LoaderTimeout   string            `json:"loader_timeout" yaml:"loader_timeout"`
+	SetTimeout      string            `json:"set_timeout" yaml:"set_timeout"`
+	DeleteTimeout   string            `json:"delete_timeout" yaml:"delete_timeout"`
+	SnapshotPath    string            `json:"snapshot_path" yaml:"snapshot_path"`
+	StatsInterval   string            `json:"stats_interval" yaml:"stats_interval"`
+	LogLevel        string            `json:"log_level" yaml:"log_level"`
+	QuietOperations *bool             `json:"quiet_operations" yaml:"quiet_operations"`
+	EncryptionKey   string            `json:"encryption_key" yaml:"encryption_key"` // hex encoded
+}
+
+// LoadConfig reads a JSON (.json) or YAML (.yaml, .yml) file and applies it on top of DefaultCacheOptions
+func LoadConfig(path string) (CacheOptions, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return CacheOptions{}, err
+	}
+
+	var cfg Config
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		err = json.Unmarshal(data, &cfg)
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &cfg)
+	default:
+		return CacheOptions{}, fmt.Errorf("lcache: unsupported config format %q", filepath.Ext(path))
+	}
+	if err != nil {
+		return CacheOptions{}, fmt.Errorf("lcache: parse %s: %w", path, err)
+	}
+
+	opts := DefaultCacheOptions()
+	if err := cfg.Apply(&opts); err != nil {
+		return CacheOptions{}, fmt.Errorf("lcache: %s: %w", path, err)
+	}
+	if err := opts.Validate(); err != nil {
+		return CacheOptions{}, fmt.Errorf("lcache: %s: %w", path, err)
+	}
+	return opts, nil
+}
+
+// Apply copies every set field of cfg into o
+func (cfg Config) Apply(o *CacheOptions) error {
+	if cfg.Name != "" {
+		o.Name = cfg.Name
+	}
+	for k, v := range cfg.Labels {
+		if o.Labels == nil {
+			o.Labels = make(map[string]string, len(cfg.Labels))
+		}
+		o.Labels[k] = v
+	}
+	if cfg.CacheType != "" {
+		o.CacheType = store.CacheType(strings.ToLower(cfg.CacheType))
+	}
+	if cfg.MaxBytes != nil {
+		o.MaxBytes = *cfg.MaxBytes
+	}
+	if cfg.MaxEntryBytes != nil {
+		o.MaxEntryBytes = *cfg.MaxEntryBytes
+	}
+	if cfg.EvictionBatch != nil {
+		o.EvictionBatch = int(*cfg.EvictionBatch)
+	}
+	if cfg.AsyncEviction != nil {
+		o.AsyncEviction = *cfg.AsyncEviction
+	}
+	if cfg.AdaptiveCleanup != nil {
+		o.AdaptiveCleanup = *cfg.AdaptiveCleanup
+	}
+	if cfg.FingerprintKeys != nil {
+		o.FingerprintKeys = *cfg.FingerprintKeys
+	}
+	if cfg.EncryptionKey != "" {
+		key, err := hex.DecodeString(cfg.EncryptionKey)
+		if err != nil {
+			return fmt.Errorf("encryption_key: %w", err)
+		}
+		o.EncryptionKey = key
+	}
+	if cfg.VerifyChecksums != nil {
+		o.VerifyChecksums = *cfg.VerifyChecksums
+	}
+	if cfg.TrackWriters != nil {
+		o.TrackWriters = *cfg.TrackWriters
+	}
+	if cfg.SnapshotPath != "" {
+		o.SnapshotPath = cfg.SnapshotPath
+	}
+	if cfg.QuietOperations != nil {
+		o.QuietOperations = *cfg.QuietOperations
+	}
+	if cfg.LogLevel != "" {
+		level, err := ParseLogLevel(cfg.LogLevel)
+		if err != nil {
+			return err
+		}
+		o.LogLevel = level
+	}
+
+	durations := []struct {
+		name  string
+		value string
+		dst   *time.Duration
+	}{
+		{"cleanup_interval", cfg.CleanupInterval, &o.CleanupTime},
+		{"default_ttl", cfg.DefaultTTL, &o.DefaultTTL},
+		{"loader_timeout", cfg.LoaderTimeout, &o.LoaderTimeout},
+		{"set_timeout", cfg.SetTimeout, &o.SetTimeout},
+		{"delete_timeout", cfg.DeleteTimeout, &o.DeleteTimeout},
+		{"stats_interval", cfg.StatsInterval, &o.StatsInterval},
+	}
+	for _, d := range durations {
+		if d.value == "" {
+			continue
+		}
+		v, err := time.ParseDuration(d.value)
+		if err != nil {
+			return fmt.Errorf("%s: %w", d.name, err)
+		}
+		*d.dst = v
+	}
+	return nil
+}
+
+// CacheOptionsFromEnv builds options from DefaultCacheOptions overridden by
+// environment variables named prefix + "_" + setting, e.g. LCACHE_MAX_BYTES,
+// LCACHE_TYPE, LCACHE_DEFAULT_TTL, LCACHE_LABELS ("k=v,k2=v2"). An empty
+// prefix means "LCACHE".
+func CacheOptionsFromEnv(prefix string) (CacheOptions, error) {
+	opts := DefaultCacheOptions()
+	if err := ApplyEnv(prefix, &opts); err != nil {
+		return CacheOptions{}, err
+	}
+	if err := opts.Validate(); err != nil {
+		return CacheOptions{}, err
+	}
+	return opts, nil
+}
+
+// ApplyEnv overrides o with any environment variables set under prefix, so it can
+// be layered on top of LoadConfig
+func ApplyEnv(prefix string, o *CacheOptions) error {
+	cfg, err := ConfigFromEnv(prefix)
+	if err != nil {
+		return err
+	}
+	return cfg.Apply(o)
+}
+
+func ConfigFromEnv(prefix string) (Config, error) {
+	if prefix == "" {
+		prefix = "LCACHE"
+	}
+	env := func(name string) string {
+		return os.Getenv(prefix + "_" + name)
+	}
+
+	cfg := Config{
+		Name:            env("NAME"),
+		CacheType:       env("TYPE"),
+		CleanupInterval: env("CLEANUP_INTERVAL"),
+		DefaultTTL:      env("DEFAULT_TTL"),
+		LoaderTimeout:   env("LOADER_TIMEOUT"),
+		SetTimeout:      env("SET_TIMEOUT"),
+		DeleteTimeout:   env("DELETE_TIMEOUT"),
+		SnapshotPath:    env("SNAPSHOT_PATH"),
+		StatsInterval:   env("STATS_INTERVAL"),
+		LogLevel:        env("LOG_LEVEL"),
+		EncryptionKey:   env("ENCRYPTION_KEY"),
+	}
+	if v := env("LABELS"); v != "" {
+		cfg.Labels = make(map[string]string)
+		for _, pair := range strings.Split(v, ",") {
+			k, val, ok := strings.Cut(pair, "=")
+			if !ok || strings.TrimSpace(k) == "" {
+				return Config{}, fmt.Errorf("lcache: %s_LABELS: want key=value, got %q", prefix, pair)
+			}
+			cfg.Labels[strings.TrimSpace(k)] = strings.TrimSpace(val)
+		}
+	}
+	for _, n := range []struct {
+		name string
+		dst  **int64
+	}{
+		{"MAX_BYTES", &cfg.MaxBytes},
+		{"MAX_ENTRY_BYTES", &cfg.MaxEntryBytes},
+		{"EVICTION_BATCH", &cfg.EvictionBatch},
+	} {
+		if v := env(n.name); v != "" {
+			i, err := strconv.ParseInt(v, 10, 64)
+			if err != nil {
+				return Config{}, fmt.Errorf("lcache: %s_%s: %w", prefix, n.name, err)
+			}
+			*n.dst = &i
+		}
+	}
+	for _, n := range []struct {
+		name string
+		dst  **bool
+	}{
+		{"QUIET_OPERATIONS", &cfg.QuietOperations},
+		{"ASYNC_EVICTION", &cfg.AsyncEviction},
+		{"ADAPTIVE_CLEANUP", &cfg.AdaptiveCleanup},
+		{"FINGERPRINT_KEYS", &cfg.FingerprintKeys},
+		{"VERIFY_CHECKSUMS", &cfg.VerifyChecksums},
+		{"TRACK_WRITERS", &cfg.TrackWriters},
+	} {
+		if v := env(n.name); v != "" {
+			b, err := strconv.ParseBool(v)
+			if err != nil {
+				return Config{}, fmt.Errorf("lcache: %s_%s: %w", prefix, n.name, err)
+			}
+			*n.dst = &b
+		}
+	}
+	return cfg, nil
+}
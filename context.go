@@ -0,0 +1,41 @@
+package LCache_go
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// context-aware variants of the cache API. They fail fast with ctx.Err() when the
+// context is already done, and pass ctx down to anything that may block. SetCtx
+// and DeleteCtx also stop waiting for the store once ctx is done.
+
+// GetCtx reads key, filling a miss through the Loader or BatchLoader when one is configured
+func (c *Cache) GetCtx(ctx context.Context, key string) (ByteView, error) {
+	if err := ctx.Err(); err != nil {
+		return ByteView{}, err
+	}
+	bv, err := c.Lookup(key)
+	if err == ErrKeyNotFound && c.hasLoader() {
+		return c.load(ctx, key)
+	}
+	return bv, err
+}
+
+// SetCtx stores value for ttl, a zero ttl falls back to DefaultTTL
+func (c *Cache) SetCtx(ctx context.Context, key string, value ByteView, ttl time.Duration) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if ttl < 0 {
+		return fmt.Errorf("%w: %v", ErrInvalidTTL, ttl)
+	}
+	return c.set(ctx, key, value, ttl)
+}
+
+func (c *Cache) DeleteCtx(ctx context.Context, key string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return c.remove(ctx, key)
+}
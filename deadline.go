@@ -0,0 +1,38 @@
+package LCache_go
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+)
+
+// bounded runs fn, giving up with ErrTimeout after d or with ctx.Err() once ctx
+// is done. fn can't be interrupted, it finishes in the background.
+func (c *Cache) bounded(ctx context.Context, d time.Duration, fn func() error) error {
+	if d <= 0 && ctx.Done() == nil {
+		return fn()
+	}
+	done := make(chan error, 1)
+	release := c.res.acquire(resGoroutine, "deadline")
+	go func() {
+		defer release()
+		done <- fn()
+	}()
+
+	var timeout <-chan time.Time
+	if d > 0 {
+		t := time.NewTimer(d)
+		defer t.Stop()
+		timeout = t.C
+	}
+	select {
+	case err := <-done:
+		return err
+	case <-timeout:
+		atomic.AddInt64(&c.timeouts, 1)
+		return ErrTimeout
+	case <-ctx.Done():
+		atomic.AddInt64(&c.timeouts, 1)
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,46 @@
+package LCache_go
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"fmt"
+)
+
+// valueCipher seals values with AES-GCM under a random nonce, using the store
+// key as additional data so a value can't be replayed under another key
+
+type valueCipher struct {
+	aead cipher.AEAD
+}
+
+func newValueCipher(key []byte) (*valueCipher, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("lcache: EncryptionKey: %w", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("lcache: EncryptionKey: %w", err)
+	}
+	return &valueCipher{aead: aead}, nil
+}
+
+// seal returns nonce || ciphertext || tag
+func (vc *valueCipher) seal(key string, plain []byte) ([]byte, error) {
+	n := vc.aead.NonceSize()
+	out := make([]byte, n, n+len(plain)+vc.aead.Overhead())
+	if _, err := rand.Read(out); err != nil {
+		return nil, err
+	}
+	return vc.aead.Seal(out, out, plain, []byte(key)), nil
+}
+
+func (vc *valueCipher) open(key string, sealed []byte) ([]byte, error) {
+	n := vc.aead.NonceSize()
+	if len(sealed) < n+vc.aead.Overhead() {
+		return nil, errors.New("sealed value too short")
+	}
+	return vc.aead.Open(nil, sealed[:n], sealed[n:], []byte(key))
+}
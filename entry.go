@@ -0,0 +1,104 @@
+package LCache_go
+
+import (
+	"fmt"
+	"lcache/store"
+	"sync/atomic"
+	"time"
+)
+
+// EntryInfo describes a cached entry without its value
+
+type EntryInfo struct {
+	Key       string    `json:"key"`
+	Size      int       `json:"size"`
+	ExpiresAt time.Time `json:"expires_at,omitempty"` // zero if the entry doesn't expire
+	Writer    string    `json:"writer,omitempty"`     // last writer's label, see CacheOptions.TrackWriters
+}
+
+// TTL returns the time left before the entry expires, or 0 if it doesn't expire
+func (e EntryInfo) TTL() time.Duration {
+	if e.ExpiresAt.IsZero() {
+		return 0
+	}
+	if ttl := time.Until(e.ExpiresAt); ttl > 0 {
+		return ttl
+	}
+	return 0
+}
+
+func newEntryInfo(key string, value store.Value, expiresAt time.Time) EntryInfo {
+	info := EntryInfo{Key: key, Size: value.Len(), ExpiresAt: expiresAt}
+	if bv, ok := value.(ByteView); ok {
+		info.Writer = bv.writer
+	}
+	return info
+}
+
+// Inspect returns metadata and the value of key without counting a hit or miss
+// and without changing its recency
+func (c *Cache) Inspect(key string) (EntryInfo, ByteView, bool) {
+	if !OpenedAndInitialized(c) {
+		return EntryInfo{}, ByteView{}, false
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.store == nil {
+		return EntryInfo{}, ByteView{}, false
+	}
+	sk := c.storeKey(key)
+	value, expiresAt, ok := c.store.Peek(sk)
+	if !ok {
+		return EntryInfo{}, ByteView{}, false
+	}
+	bv, _ := value.(ByteView)
+	bv, err := c.decodeValue(sk, bv)
+	if err != nil {
+		c.dropCorrupt(sk, err)
+		return EntryInfo{}, ByteView{}, false
+	}
+	return newEntryInfo(key, value, expiresAt), bv, true
+}
+
+// Has reports whether key is cached, without counting a hit or miss, changing
+// its recency or copying its value
+func (c *Cache) Has(key string) bool {
+	if !OpenedAndInitialized(c) {
+		return false
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.store != nil && c.store.Has(c.storeKey(key))
+}
+
+// CountPrefix counts the cached keys starting with prefix
+func (c *Cache) CountPrefix(prefix string) int {
+	if atomic.LoadInt32(&c.closed) == 1 || atomic.LoadInt32(&c.initialized) == 0 {
+		return 0
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.store == nil {
+		return 0
+	}
+	return c.store.CountPrefix(prefix)
+}
+
+// Expire changes the ttl of an existing key, a zero ttl makes it persistent
+func (c *Cache) Expire(key string, ttl time.Duration) error {
+	if ttl < 0 {
+		return fmt.Errorf("%w: %v", ErrInvalidTTL, ttl)
+	}
+	if !OpenedAndInitialized(c) {
+		return ErrCacheClosed
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.store == nil {
+		return ErrCacheClosed
+	}
+	if !c.store.Expire(c.storeKey(key), ttl) {
+		return ErrKeyNotFound
+	}
+	return nil
+}
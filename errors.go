@@ -0,0 +1,41 @@
+package LCache_go
+
+import (
+	"errors"
+	"lcache/store"
+)
+
+var (
+	ErrCacheClosed   = errors.New("lcache: cache is closed")
+	ErrKeyNotFound   = errors.New("lcache: key not found")
+	ErrValueTooLarge = errors.New("lcache: value too large")
+	ErrInvalidTTL    = errors.New("lcache: invalid ttl")
+	ErrLoaderFailed  = errors.New("lcache: loader failed")
+	// ErrLoadShed means a miss wasn't loaded because too many loads were running
+	ErrLoadShed = errors.New("lcache: load shed")
+	// ErrBreakerOpen means a miss wasn't loaded because the loader kept failing
+	ErrBreakerOpen    = errors.New("lcache: loader circuit breaker open")
+	ErrNotSupported   = errors.New("lcache: not supported by the store")
+	ErrNotReady       = errors.New("lcache: not ready")
+	ErrBulkLoadActive = errors.New("lcache: a bulk load is already in progress")
+	// ErrValueCodec means EncodeValue or DecodeValue failed
+	ErrValueCodec = errors.New("lcache: value encoding failed")
+	// ErrValueCorrupt means a value failed its checksum, see VerifyChecksums
+	ErrValueCorrupt = errors.New("lcache: value corrupt")
+	// ErrTimeout means a store call outlived SetTimeout or DeleteTimeout, it
+	// keeps running in the background and may still take effect
+	ErrTimeout = errors.New("lcache: operation timed out")
+	// ErrInjectedFault is a failure injected by StoreFaults or LoaderFaults
+	ErrInjectedFault = errors.New("lcache: injected fault")
+	// ErrTombstoned means the key was deleted with DeleteWithDelay and its window
+	// hasn't ended yet
+	ErrTombstoned = errors.New("lcache: key is tombstoned")
+	// ErrRateLimited means a namespace ran out of its rate limit, see SetRateLimit
+	ErrRateLimited = errors.New("lcache: rate limited")
+	// ErrTxDone means a Txn was used after its Tx callback returned
+	ErrTxDone        = errors.New("lcache: transaction already finished")
+	ErrQuotaExceeded = store.ErrQuotaExceeded
+	// ErrVersionMismatch means the entry changed since its version was read
+	ErrVersionMismatch = store.ErrVersionMismatch
+	ErrKeyExists       = store.ErrKeyExists
+)
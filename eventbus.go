@@ -0,0 +1,256 @@
+package LCache_go
+
+import (
+	"context"
+	"lcache/store"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// EventType and its values mirror the store events delivered by Watch and Subscribe
+type EventType = store.EventType
+
+const (
+	EventSet    = store.EventSet
+	EventUpdate = store.EventUpdate
+	EventDelete = store.EventDelete
+	EventEvict  = store.EventEvict
+	EventExpire = store.EventExpire
+	EventClear  = store.EventClear
+)
+
+// KeyEvent reports a change to a cache entry
+
+type KeyEvent struct {
+	Type  EventType
+	Cache string   // Name of the cache the event came from
+	Key   string   // empty for EventClear
+	Value ByteView // the new value for set and update, the removed one otherwise
+	Time  time.Time
+}
+
+// defaultEventBuffer is the channel capacity of a subscription
+const defaultEventBuffer = 64
+
+// eventBus fans store events out to any number of subscribers. Publishing never
+// blocks: a subscriber that falls behind loses its oldest undelivered event,
+// except for lossless ones, which queue events without bound.
+
+type eventBus struct {
+	mu      sync.RWMutex
+	subs    map[*subscription]struct{}
+	counts  [EventClear + 1]int64 // published events by type
+	dropped int64
+}
+
+type subscription struct {
+	ch     chan KeyEvent
+	done   chan struct{}       // closed along with ch
+	filter func(KeyEvent) bool // nil accepts everything
+
+	// lossless subscriptions get their events through queue instead of ch
+	wake  chan struct{} // nil unless lossless, closed instead of ch
+	qmu   sync.Mutex
+	queue []KeyEvent
+}
+
+func (b *eventBus) subscribe(buffer int, filter func(KeyEvent) bool) *subscription {
+	if buffer <= 0 {
+		buffer = defaultEventBuffer
+	}
+	s := &subscription{ch: make(chan KeyEvent, buffer), done: make(chan struct{}), filter: filter}
+	b.add(s)
+	return s
+}
+
+// subscribeLossless never drops events, it queues them until take is called
+func (b *eventBus) subscribeLossless(filter func(KeyEvent) bool) *subscription {
+	s := &subscription{wake: make(chan struct{}, 1), done: make(chan struct{}), filter: filter}
+	b.add(s)
+	return s
+}
+
+func (b *eventBus) add(s *subscription) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.subs == nil {
+		b.subs = make(map[*subscription]struct{})
+	}
+	b.subs[s] = struct{}{}
+}
+
+// take detaches the queued events of a lossless subscription
+func (s *subscription) take() []KeyEvent {
+	s.qmu.Lock()
+	defer s.qmu.Unlock()
+	q := s.queue
+	s.queue = nil
+	return q
+}
+
+// close ends s, b.mu must be held
+func (s *subscription) close() {
+	if s.wake != nil {
+		close(s.wake)
+	} else {
+		close(s.ch)
+	}
+	close(s.done)
+}
+
+func (b *eventBus) unsubscribe(s *subscription) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, ok := b.subs[s]; ok {
+		delete(b.subs, s)
+		s.close()
+	}
+}
+
+// active reports whether anyone is subscribed
+func (b *eventBus) active() bool {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return len(b.subs) > 0
+}
+
+// closeAll ends every subscription, used when the cache closes
+func (b *eventBus) closeAll() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for s := range b.subs {
+		delete(b.subs, s)
+		s.close()
+	}
+}
+
+func (b *eventBus) publish(ev KeyEvent) {
+	if ev.Type > 0 && int(ev.Type) < len(b.counts) {
+		atomic.AddInt64(&b.counts[ev.Type], 1)
+	}
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	for s := range b.subs {
+		if s.filter != nil && !s.filter(ev) {
+			continue
+		}
+		if s.wake != nil {
+			s.qmu.Lock()
+			s.queue = append(s.queue, ev)
+			s.qmu.Unlock()
+			select {
+			case s.wake <- struct{}{}:
+			default:
+			}
+			continue
+		}
+		select {
+		case s.ch <- ev:
+			continue
+		default:
+		}
+		// full: drop the oldest event to make room
+		select {
+		case <-s.ch:
+			atomic.AddInt64(&b.dropped, 1)
+		default:
+		}
+		select {
+		case s.ch <- ev:
+		default:
+			atomic.AddInt64(&b.dropped, 1)
+		}
+	}
+}
+
+// stats adds events_<type> and events_dropped counters
+func (b *eventBus) stats(stats Stats) {
+	for t := EventSet; t <= EventClear; t++ {
+		stats["events_"+t.String()] = atomic.LoadInt64(&b.counts[t])
+	}
+	stats["events_dropped"] = atomic.LoadInt64(&b.dropped)
+}
+
+func (b *eventBus) reset() {
+	for i := range b.counts {
+		atomic.StoreInt64(&b.counts[i], 0)
+	}
+	atomic.StoreInt64(&b.dropped, 0)
+}
+
+// Subscribe delivers cache events of the given types (all types when none are
+// given) until ctx is done or the cache closes, then closes the channel. buffer
+// sets the channel capacity, 0 picks a default; when it is full the oldest
+// undelivered event is dropped and counted in events_dropped.
+func (c *Cache) Subscribe(ctx context.Context, buffer int, types ...EventType) <-chan KeyEvent {
+	var filter func(KeyEvent) bool
+	if len(types) > 0 {
+		filter = func(ev KeyEvent) bool {
+			for _, t := range types {
+				if ev.Type == t {
+					return true
+				}
+			}
+			return false
+		}
+	}
+	return c.subscribe(ctx, buffer, filter)
+}
+
+func (c *Cache) subscribe(ctx context.Context, buffer int, filter func(KeyEvent) bool) <-chan KeyEvent {
+	if !OpenedAndInitialized(c) {
+		ch := make(chan KeyEvent)
+		close(ch)
+		return ch
+	}
+	s := c.events.subscribe(buffer, filter)
+	done := c.res.acquire(resGoroutine, "watch")
+	go func() {
+		defer done()
+		select {
+		case <-ctx.Done():
+			c.events.unsubscribe(s)
+		case <-s.done:
+		}
+	}()
+	return s.ch
+}
+
+// onStoreEvent is the store listener, it runs under the store lock and never blocks
+func (c *Cache) onStoreEvent(e store.Event) {
+	ev := KeyEvent{Type: e.Type, Cache: c.opts.Name, Key: e.Key, Time: time.Now()}
+	if bv, ok := e.Value.(ByteView); ok {
+		// evictions may be spilled to a bulk load's overflow cache
+		if !c.transformsValues() || ev.Type == EventEvict || c.events.active() {
+			ev.Value, _ = c.decodeValue(e.Key, bv)
+		}
+	}
+	if ev.Type == EventEvict {
+		c.spill(ev)
+	}
+	c.events.publish(ev)
+}
+
+// startCallback runs fn for every event of type t off the event bus, so
+// OnEvicted and OnExpired may call back into the cache. Its subscription is
+// lossless, a burst of evictions queues up rather than skipping fn. It stops
+// when the cache closes, after running fn for what was already queued.
+func (c *Cache) startCallback(t EventType, owner string, fn func(key string, value store.Value)) {
+	s := c.events.subscribeLossless(func(ev KeyEvent) bool { return ev.Type == t })
+	done := c.res.acquire(resGoroutine, owner)
+	go func() {
+		defer done()
+		drain := func() {
+			for q := s.take(); len(q) > 0; q = s.take() {
+				for _, ev := range q {
+					fn(ev.Key, ev.Value)
+				}
+			}
+		}
+		for range s.wake {
+			drain()
+		}
+		drain()
+	}()
+}
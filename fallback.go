@@ -0,0 +1,91 @@
+package LCache_go
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// FallbackLoader is one step of CacheOptions.FallbackLoaders
+
+type FallbackLoader struct {
+	Name string // Reported in Stats as loader_<name>_hits, _misses and _errors
+	Load LoaderFunc
+}
+
+// fallbackChain tries its loaders in order until one returns a value. A miss
+// (ErrKeyNotFound) or an error moves on to the next loader; the chain misses
+// only if every loader missed and fails with the joined errors otherwise.
+
+type fallbackChain struct {
+	loaders []FallbackLoader
+	counts  []fallbackCounts
+}
+
+type fallbackCounts struct {
+	hits, misses, errors int64
+}
+
+func newFallbackChain(loaders []FallbackLoader) *fallbackChain {
+	return &fallbackChain{
+		loaders: loaders,
+		counts:  make([]fallbackCounts, len(loaders)),
+	}
+}
+
+func validateFallbacks(loaders []FallbackLoader) error {
+	seen := make(map[string]bool, len(loaders))
+	for _, l := range loaders {
+		if l.Name == "" || l.Load == nil {
+			return errors.New("lcache: every FallbackLoader needs a Name and Load")
+		}
+		if seen[l.Name] {
+			return fmt.Errorf("lcache: duplicate FallbackLoader %q", l.Name)
+		}
+		seen[l.Name] = true
+	}
+	return nil
+}
+
+func (f *fallbackChain) load(ctx context.Context, key string) (ByteView, time.Duration, error) {
+	var errs []error
+	for i, l := range f.loaders {
+		if err := ctx.Err(); err != nil {
+			errs = append(errs, err)
+			break
+		}
+		val, ttl, err := l.Load(ctx, key)
+		switch {
+		case err == nil:
+			atomic.AddInt64(&f.counts[i].hits, 1)
+			return val, ttl, nil
+		case errors.Is(err, ErrKeyNotFound):
+			atomic.AddInt64(&f.counts[i].misses, 1)
+		default:
+			atomic.AddInt64(&f.counts[i].errors, 1)
+			errs = append(errs, fmt.Errorf("%s: %w", l.Name, err))
+		}
+	}
+	if len(errs) == 0 {
+		return ByteView{}, 0, ErrKeyNotFound
+	}
+	return ByteView{}, 0, errors.Join(errs...)
+}
+
+func (f *fallbackChain) stats(stats Stats) {
+	for i, l := range f.loaders {
+		stats["loader_"+l.Name+"_hits"] = atomic.LoadInt64(&f.counts[i].hits)
+		stats["loader_"+l.Name+"_misses"] = atomic.LoadInt64(&f.counts[i].misses)
+		stats["loader_"+l.Name+"_errors"] = atomic.LoadInt64(&f.counts[i].errors)
+	}
+}
+
+func (f *fallbackChain) reset() {
+	for i := range f.counts {
+		atomic.StoreInt64(&f.counts[i].hits, 0)
+		atomic.StoreInt64(&f.counts[i].misses, 0)
+		atomic.StoreInt64(&f.counts[i].errors, 0)
+	}
+}
@@ -0,0 +1,131 @@
+package LCache_go
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// FaultOptions describe failures to inject for resilience tests, see
+// CacheOptions.StoreFaults and LoaderFaults. Rates are probabilities in [0, 1].
+// Not meant for production.
+
+type FaultOptions struct {
+	Latency   time.Duration // Added to every call
+	Jitter    time.Duration // Up to this much random latency on top of Latency
+	ErrorRate float64       // Calls failing with ErrInjectedFault
+	MissRate  float64       // Reads reporting a miss although the key is there
+	DropRate  float64       // Store writes reported successful but never stored
+	Seed      int64         // Seeds the dice, so a failing test can be replayed
+}
+
+func (o FaultOptions) validate() error {
+	if o.Latency < 0 || o.Jitter < 0 {
+		return fmt.Errorf("lcache: fault Latency and Jitter must not be negative")
+	}
+	for _, r := range []float64{o.ErrorRate, o.MissRate, o.DropRate} {
+		if r < 0 || r > 1 {
+			return fmt.Errorf("lcache: fault rates must be in [0, 1], got %v", r)
+		}
+	}
+	return nil
+}
+
+// faultInjector rolls the dice for one fault point and counts what it injected.
+// Lookup, storeValue and remove pass the store fault point, fetch the loader one.
+
+type faultInjector struct {
+	opts FaultOptions
+
+	mu  sync.Mutex
+	rnd *rand.Rand
+
+	delays int64
+	errors int64
+	misses int64
+	drops  int64
+}
+
+func newFaultInjector(opts FaultOptions) *faultInjector {
+	return &faultInjector{opts: opts, rnd: rand.New(rand.NewSource(opts.Seed))}
+}
+
+func (f *faultInjector) roll(rate float64) bool {
+	if rate <= 0 {
+		return false
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.rnd.Float64() < rate
+}
+
+// inject delays and then rolls for an error or a miss, the checks every read
+// fault point makes
+func (f *faultInjector) inject(ctx context.Context) error {
+	if err := f.delay(ctx); err != nil {
+		return err
+	}
+	if err := f.fail(); err != nil {
+		return err
+	}
+	if f.miss() {
+		return ErrKeyNotFound
+	}
+	return nil
+}
+
+// delay sleeps for the configured latency or until ctx is done
+func (f *faultInjector) delay(ctx context.Context) error {
+	d := f.opts.Latency
+	if f.opts.Jitter > 0 {
+		f.mu.Lock()
+		d += time.Duration(f.rnd.Int63n(int64(f.opts.Jitter)))
+		f.mu.Unlock()
+	}
+	if d <= 0 {
+		return nil
+	}
+	atomic.AddInt64(&f.delays, 1)
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (f *faultInjector) fail() error {
+	if !f.roll(f.opts.ErrorRate) {
+		return nil
+	}
+	atomic.AddInt64(&f.errors, 1)
+	return ErrInjectedFault
+}
+
+func (f *faultInjector) miss() bool {
+	if !f.roll(f.opts.MissRate) {
+		return false
+	}
+	atomic.AddInt64(&f.misses, 1)
+	return true
+}
+
+func (f *faultInjector) drop() bool {
+	if !f.roll(f.opts.DropRate) {
+		return false
+	}
+	atomic.AddInt64(&f.drops, 1)
+	return true
+}
+
+func (f *faultInjector) stats(stats Stats, prefix string) {
+	stats[prefix+"_delays"] = atomic.LoadInt64(&f.delays)
+	stats[prefix+"_errors"] = atomic.LoadInt64(&f.errors)
+	stats[prefix+"_misses"] = atomic.LoadInt64(&f.misses)
+	stats[prefix+"_drops"] = atomic.LoadInt64(&f.drops)
+}
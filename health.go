@@ -0,0 +1,42 @@
+package LCache_go
+
+import (
+	"fmt"
+	"sync/atomic"
+)
+
+// Live reports whether c is still usable, it fails only once the cache is closed
+func (c *Cache) Live() error {
+	if atomic.LoadInt32(&c.closed) == 1 {
+		return ErrCacheClosed
+	}
+	return nil
+}
+
+// MarkDraining makes Ready fail from now on while the cache keeps serving, so a
+// load balancer stops sending traffic before a graceful shutdown
+func (c *Cache) MarkDraining() {
+	atomic.StoreInt32(&c.draining, 1)
+}
+
+// Ready reports whether c can serve traffic: it is open, not draining, its
+// store was created and it is within its memory budget. It initializes the
+// cache if needed.
+func (c *Cache) Ready() error {
+	if !OpenedAndInitialized(c) {
+		return ErrCacheClosed
+	}
+	if atomic.LoadInt32(&c.draining) == 1 {
+		return fmt.Errorf("%w: draining", ErrNotReady)
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.store == nil {
+		return fmt.Errorf("%w: store not created", ErrNotReady)
+	}
+	used, max := c.store.UsedBytes(), atomic.LoadInt64(&c.maxBytes)
+	if max > 0 && used > max {
+		return fmt.Errorf("%w: using %d of %d bytes", ErrNotReady, used, max)
+	}
+	return nil
+}
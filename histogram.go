@@ -0,0 +1,184 @@
+package LCache_go
+
+import (
+	"math/bits"
+	"sync/atomic"
+	"time"
+)
+
+// log-linear histogram in the spirit of HdrHistogram: every power of two is split
+// into 2^histSubBits linear sub-buckets, giving ~12% relative error at any magnitude.
+// Recording is a handful of atomic adds, so it is cheap enough for the hot path.
+
+const (
+	histSubBits    = 3
+	histSubBuckets = 1 << histSubBits
+	histBuckets    = (64 - histSubBits + 1) * histSubBuckets
+)
+
+type Histogram struct {
+	counts [histBuckets]int64
+	count  int64
+	sum    int64
+	max    int64
+}
+
+func histBucket(v int64) int {
+	if v < histSubBuckets {
+		return int(v)
+	}
+	exp := bits.Len64(uint64(v)) - 1
+	sub := int(v>>uint(exp-histSubBits)) & (histSubBuckets - 1)
+	return (exp-histSubBits+1)*histSubBuckets + sub
+}
+
+// histUpperBound returns the largest value that falls into bucket idx
+func histUpperBound(idx int) int64 {
+	if idx < histSubBuckets {
+		return int64(idx)
+	}
+	exp := idx/histSubBuckets + histSubBits - 1
+	sub := int64(idx % histSubBuckets)
+	shift := uint(exp - histSubBits)
+	return (histSubBuckets+sub+1)<<shift - 1
+}
+
+// Record adds a single observation; negative values are counted as zero
+func (h *Histogram) Record(v int64) {
+	if v < 0 {
+		v = 0
+	}
+	atomic.AddInt64(&h.counts[histBucket(v)], 1)
+	atomic.AddInt64(&h.count, 1)
+	atomic.AddInt64(&h.sum, v)
+	for {
+		m := atomic.LoadInt64(&h.max)
+		if v <= m || atomic.CompareAndSwapInt64(&h.max, m, v) {
+			return
+		}
+	}
+}
+
+func (h *Histogram) Count() int64 {
+	return atomic.LoadInt64(&h.count)
+}
+
+func (h *Histogram) Max() int64 {
+	return atomic.LoadInt64(&h.max)
+}
+
+func (h *Histogram) Mean() float64 {
+	n := atomic.LoadInt64(&h.count)
+	if n == 0 {
+		return 0
+	}
+	return float64(atomic.LoadInt64(&h.sum)) / float64(n)
+}
+
+// Quantile returns the upper bound of the bucket holding the q-th quantile, 0 <= q <= 1
+func (h *Histogram) Quantile(q float64) int64 {
+	n := atomic.LoadInt64(&h.count)
+	if n == 0 {
+		return 0
+	}
+	rank := int64(q * float64(n))
+	if rank < 1 {
+		rank = 1
+	}
+	var seen int64
+	for i := range h.counts {
+		seen += atomic.LoadInt64(&h.counts[i])
+		if seen >= rank {
+			if ub := histUpperBound(i); ub < h.Max() {
+				return ub
+			}
+			return h.Max()
+		}
+	}
+	return h.Max()
+}
+
+func (h *Histogram) P50() int64 { return h.Quantile(0.50) }
+func (h *Histogram) P95() int64 { return h.Quantile(0.95) }
+func (h *Histogram) P99() int64 { return h.Quantile(0.99) }
+
+type HistogramBucket struct {
+	UpperBound int64 `json:"upper_bound"`
+	Count      int64 `json:"count"`
+}
+
+// Buckets returns the non-empty buckets in ascending order
+func (h *Histogram) Buckets() []HistogramBucket {
+	var buckets []HistogramBucket
+	for i := range h.counts {
+		if n := atomic.LoadInt64(&h.counts[i]); n > 0 {
+			buckets = append(buckets, HistogramBucket{UpperBound: histUpperBound(i), Count: n})
+		}
+	}
+	return buckets
+}
+
+func (h *Histogram) Reset() {
+	for i := range h.counts {
+		atomic.StoreInt64(&h.counts[i], 0)
+	}
+	atomic.StoreInt64(&h.count, 0)
+	atomic.StoreInt64(&h.sum, 0)
+	atomic.StoreInt64(&h.max, 0)
+}
+
+// operation latency tracking
+
+const (
+	OpGet    = "get"
+	OpSet    = "set"
+	OpDelete = "delete"
+)
+
+type LatencyStats struct {
+	Count int64
+	P50   time.Duration
+	P95   time.Duration
+	P99   time.Duration
+	Max   time.Duration
+}
+
+type latencyTracker struct {
+	ops map[string]*Histogram
+}
+
+func newLatencyTracker() *latencyTracker {
+	return &latencyTracker{
+		ops: map[string]*Histogram{
+			OpGet:    {},
+			OpSet:    {},
+			OpDelete: {},
+		},
+	}
+}
+
+func (t *latencyTracker) observe(op string, start time.Time) {
+	if h, ok := t.ops[op]; ok {
+		h.Record(int64(time.Since(start)))
+	}
+}
+
+func (t *latencyTracker) stats(op string) LatencyStats {
+	h, ok := t.ops[op]
+	if !ok {
+		return LatencyStats{}
+	}
+	return LatencyStats{
+		Count: h.Count(),
+		P50:   time.Duration(h.P50()),
+		P95:   time.Duration(h.P95()),
+		P99:   time.Duration(h.P99()),
+		Max:   time.Duration(h.Max()),
+	}
+}
+
+func (t *latencyTracker) reset() {
+	for _, h := range t.ops {
+		h.Reset()
+	}
+}
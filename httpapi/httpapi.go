@@ -0,0 +1,171 @@
+// Package httpapi exposes a cache as a small REST API.
+package httpapi
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	lcache "lcache"
+	"lcache/auth"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// routes:
+//
+//	GET    /cache/{key}   the value as the body, 404 on a miss
+//	HEAD   /cache/{key}   headers only
+//	PUT    /cache/{key}   store the request body
+//	DELETE /cache/{key}   delete an entry
+//
+// PUT takes the ttl from the TTLHeader request header, either a duration like
+// "90s" or a number of seconds. GET and HEAD answer with an ETag built from the
+// value hash, honour If-None-Match with 304, and report the remaining ttl in
+// TTLHeader along with Expires when the entry expires.
+
+const TTLHeader = "X-Cache-TTL"
+
+// maxValueBytes bounds request bodies accepted by PUT
+const maxValueBytes = 64 << 20
+
+type Handler struct {
+	cache *lcache.Cache
+	auth  *auth.Authenticator
+}
+
+func NewHandler(c *lcache.Cache) *Handler {
+	return &Handler{cache: c}
+}
+
+// WithAuth requires credentials from a: read access for GET and HEAD, write
+// access for PUT and DELETE
+func (h *Handler) WithAuth(a *auth.Authenticator) *Handler {
+	h.auth = a
+	return h
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !h.auth.Check(w, r, auth.MethodRole(r)) {
+		return
+	}
+	key := strings.TrimPrefix(r.URL.Path, "/cache/")
+	if key == "" || key == r.URL.Path {
+		writeError(w, http.StatusNotFound, "not found")
+		return
+	}
+	switch r.Method {
+	case http.MethodGet, http.MethodHead:
+		h.get(w, r, key)
+	case http.MethodPut:
+		h.put(w, r, key)
+	case http.MethodDelete:
+		h.delete(w, r, key)
+	default:
+		w.Header().Set("Allow", "GET, HEAD, PUT, DELETE")
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+func (h *Handler) get(w http.ResponseWriter, r *http.Request, key string) {
+	value, err := h.cache.GetCtx(r.Context(), key)
+	switch {
+	case err == nil:
+	case errors.Is(err, lcache.ErrKeyNotFound):
+		writeError(w, http.StatusNotFound, err.Error())
+		return
+	case errors.Is(err, lcache.ErrLoaderFailed):
+		writeError(w, http.StatusBadGateway, err.Error())
+		return
+	default:
+		writeError(w, http.StatusServiceUnavailable, err.Error())
+		return
+	}
+
+	etag := `"` + strconv.FormatUint(value.Hash(), 16) + `"`
+	header := w.Header()
+	header.Set("ETag", etag)
+	if info, _, ok := h.cache.Inspect(key); ok && !info.ExpiresAt.IsZero() {
+		header.Set(TTLHeader, strconv.FormatInt(int64(info.TTL().Seconds()), 10))
+		header.Set("Expires", info.ExpiresAt.UTC().Format(http.TimeFormat))
+	}
+	if etagMatches(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	header.Set("Content-Type", "application/octet-stream")
+	header.Set("Content-Length", strconv.Itoa(value.Len()))
+	w.WriteHeader(http.StatusOK)
+	if r.Method != http.MethodHead {
+		w.Write(value.ByteSlice())
+	}
+}
+
+func (h *Handler) put(w http.ResponseWriter, r *http.Request, key string) {
+	ttl, err := parseTTL(r.Header.Get(TTLHeader))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxValueBytes))
+	if err != nil {
+		writeError(w, http.StatusRequestEntityTooLarge, err.Error())
+		return
+	}
+	value := lcache.NewByteView(body)
+	switch err := h.cache.SetCtx(r.Context(), key, value, ttl); {
+	case err == nil:
+		w.Header().Set("ETag", `"`+strconv.FormatUint(value.Hash(), 16)+`"`)
+		w.WriteHeader(http.StatusNoContent)
+	case errors.Is(err, lcache.ErrValueTooLarge), errors.Is(err, lcache.ErrQuotaExceeded):
+		writeError(w, http.StatusRequestEntityTooLarge, err.Error())
+	default:
+		writeError(w, http.StatusServiceUnavailable, err.Error())
+	}
+}
+
+func (h *Handler) delete(w http.ResponseWriter, r *http.Request, key string) {
+	switch err := h.cache.DeleteCtx(r.Context(), key); {
+	case err == nil:
+		w.WriteHeader(http.StatusNoContent)
+	case errors.Is(err, lcache.ErrKeyNotFound):
+		writeError(w, http.StatusNotFound, err.Error())
+	default:
+		writeError(w, http.StatusServiceUnavailable, err.Error())
+	}
+}
+
+// parseTTL accepts a duration like "90s" or a plain number of seconds
+func parseTTL(v string) (time.Duration, error) {
+	if v == "" {
+		return 0, nil
+	}
+	if secs, err := strconv.ParseInt(v, 10, 64); err == nil && secs >= 0 {
+		return time.Duration(secs) * time.Second, nil
+	}
+	if ttl, err := time.ParseDuration(v); err == nil && ttl >= 0 {
+		return ttl, nil
+	}
+	return 0, errors.New(TTLHeader + " must be a non-negative duration or number of seconds")
+}
+
+func etagMatches(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, msg string) {
+	writeJSON(w, status, map[string]string{"error": msg})
+}
@@ -0,0 +1,74 @@
+package LCache_go
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// delayedDeletes holds the second deletes scheduled by InvalidateTwice until
+// they fire or the cache closes
+
+type delayedDeletes struct {
+	mu      sync.Mutex
+	timers  map[*time.Timer]func() // pending delete, and the release of its resource
+	removed int64                  // second deletes that found the key repopulated
+}
+
+// schedule runs fn after d unless stopAll comes first
+func (d *delayedDeletes) schedule(res *resourceTracker, delay time.Duration, fn func()) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.timers == nil {
+		d.timers = make(map[*time.Timer]func())
+	}
+	var t *time.Timer
+	t = time.AfterFunc(delay, func() {
+		d.mu.Lock()
+		release, ok := d.timers[t]
+		delete(d.timers, t)
+		d.mu.Unlock()
+		if ok {
+			defer release()
+			fn()
+		}
+	})
+	d.timers[t] = res.acquire(resTicker, "delayed_delete")
+}
+
+// stopAll cancels the deletes that haven't fired yet
+func (d *delayedDeletes) stopAll() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for t, release := range d.timers {
+		t.Stop()
+		release()
+	}
+	d.timers = nil
+}
+
+func (d *delayedDeletes) stats(stats Stats) {
+	d.mu.Lock()
+	stats["delayed_deletes_pending"] = len(d.timers)
+	d.mu.Unlock()
+	stats["delayed_deletes_removed"] = atomic.LoadInt64(&d.removed)
+}
+
+// InvalidateTwice deletes key now and again after delay, the double delete of
+// cache-aside setups: a reader that fetched the old value from a lagging
+// replica may put it back after the first delete, the second one removes it
+// once replication has caught up. A key that isn't cached is not an error.
+// Pending second deletes are dropped when the cache closes.
+func (c *Cache) InvalidateTwice(key string, delay time.Duration) error {
+	if err := c.Remove(key); err != nil && err != ErrKeyNotFound {
+		return err
+	}
+	if delay > 0 {
+		c.delayed.schedule(&c.res, delay, func() {
+			if c.Remove(key) == nil {
+				atomic.AddInt64(&c.delayed.removed, 1)
+			}
+		})
+	}
+	return nil
+}
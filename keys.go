@@ -0,0 +1,27 @@
+package LCache_go
+
+import "hash/fnv"
+
+// normalizeKey applies KeyTransform, if any
+func (c *Cache) normalizeKey(key string) string {
+	if c.opts.KeyTransform != nil {
+		return c.opts.KeyTransform(key)
+	}
+	return key
+}
+
+// storeKey maps a caller's key to the key used in the store
+func (c *Cache) storeKey(key string) string {
+	key = c.normalizeKey(key)
+	if c.opts.FingerprintKeys {
+		return fingerprint(key)
+	}
+	return key
+}
+
+// fingerprint returns the 128-bit FNV-1a hash of key as a 16 byte string
+func fingerprint(key string) string {
+	h := fnv.New128a()
+	h.Write([]byte(key))
+	return string(h.Sum(nil))
+}
@@ -0,0 +1,22 @@
+package LCache_go
+
+// Name returns CacheOptions.Name
+func (c *Cache) Name() string {
+	return c.opts.Name
+}
+
+// Labels returns a copy of CacheOptions.Labels
+func (c *Cache) Labels() map[string]string {
+	return copyLabels(c.opts.Labels)
+}
+
+func copyLabels(labels map[string]string) map[string]string {
+	if labels == nil {
+		return nil
+	}
+	out := make(map[string]string, len(labels))
+	for k, v := range labels {
+		out[k] = v
+	}
+	return out
+}
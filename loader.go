@@ -0,0 +1,125 @@
+package LCache_go
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// LoaderFunc fetches the value for key on a cache miss. The returned ttl is used
+// when storing the value, zero falls back to DefaultTTL. Returning ErrKeyNotFound
+// reports a plain miss rather than a failure.
+type LoaderFunc func(ctx context.Context, key string) (ByteView, time.Duration, error)
+
+// flightGroup deduplicates concurrent loads of the same key
+
+type flightCall struct {
+	done chan struct{}
+	val  ByteView
+	ttl  time.Duration
+	err  error
+}
+
+type flightGroup struct {
+	mu    sync.Mutex
+	calls map[string]*flightCall
+}
+
+// do runs fn once per key at a time, the second return value reports whether
+// the caller joined a load started by someone else
+func (g *flightGroup) do(key string, fn func() (ByteView, time.Duration, error)) (*flightCall, bool) {
+	g.mu.Lock()
+	if g.calls == nil {
+		g.calls = make(map[string]*flightCall)
+	}
+	if call, ok := g.calls[key]; ok {
+		g.mu.Unlock()
+		return call, true
+	}
+	call := &flightCall{done: make(chan struct{})}
+	g.calls[key] = call
+	g.mu.Unlock()
+
+	go func() {
+		call.val, call.ttl, call.err = fn()
+		g.mu.Lock()
+		delete(g.calls, key)
+		g.mu.Unlock()
+		close(call.done)
+	}()
+	return call, false
+}
+
+// load fills a miss for key through the configured loader. The load itself is
+// detached from ctx cancellation so one impatient caller doesn't fail everyone
+// waiting on the same key, but each caller stops waiting when its own ctx is done.
+func (c *Cache) load(ctx context.Context, key string) (ByteView, error) {
+	flightKey := c.normalizeKey(key)
+	if c.absent != nil && c.absent.absent(flightKey) {
+		return ByteView{}, ErrKeyNotFound
+	}
+	call, shared := c.loads.do(flightKey, func() (ByteView, time.Duration, error) {
+		defer c.res.acquire(resGoroutine, "loader")()
+		running := atomic.AddInt64(&c.loading, 1) - 1
+		defer atomic.AddInt64(&c.loading, -1)
+		if c.shouldShed(running) {
+			atomic.AddInt64(&c.loadsShed, 1)
+			return ByteView{}, 0, ErrLoadShed
+		}
+		atomic.AddInt64(&c.inflight, 1)
+		defer atomic.AddInt64(&c.inflight, -1)
+		atomic.AddInt64(&c.loadCount, 1)
+
+		if c.breaker != nil {
+			if !c.breaker.allow() {
+				return ByteView{}, 0, ErrBreakerOpen
+			}
+		}
+
+		loadCtx := context.WithoutCancel(ctx)
+		if c.opts.LoaderTimeout > 0 {
+			var cancel context.CancelFunc
+			loadCtx, cancel = context.WithTimeout(loadCtx, c.opts.LoaderTimeout)
+			defer cancel()
+		}
+		val, ttl, err := c.fetchWithRetry(loadCtx, key)
+		if c.breaker != nil {
+			c.breaker.done(err != nil && !errors.Is(err, ErrKeyNotFound))
+		}
+		if err != nil {
+			if errors.Is(err, ErrKeyNotFound) && c.absent != nil {
+				c.absent.add(flightKey)
+			} else if !errors.Is(err, ErrKeyNotFound) {
+				atomic.AddInt64(&c.loadErrors, 1)
+				c.opLog(LevelWarn, "Loader failed", OpGet, key, "error", err)
+			}
+			return val, ttl, err
+		}
+		if err := c.checkSize(key, val); err != nil {
+			c.opLog(LevelWarn, "Loaded value not cached", OpSet, key, "error", err)
+		} else if err := c.storeValue(key, val, ttl, c.loaderWriter()); err != nil {
+			c.opLog(LevelWarn, "Failed to cache loaded value", OpSet, key, "error", err)
+		}
+		return val, ttl, nil
+	})
+	if shared {
+		atomic.AddInt64(&c.loadsDeduped, 1)
+	}
+
+	select {
+	case <-ctx.Done():
+		return ByteView{}, ctx.Err()
+	case <-call.done:
+	}
+
+	if call.err != nil {
+		if errors.Is(call.err, ErrKeyNotFound) || errors.Is(call.err, ErrLoadShed) || errors.Is(call.err, ErrBreakerOpen) {
+			return ByteView{}, call.err
+		}
+		return ByteView{}, fmt.Errorf("%w: %w", ErrLoaderFailed, call.err)
+	}
+	return call.val, nil
+}
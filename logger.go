@@ -0,0 +1,184 @@
+package LCache_go
+
+import (
+	"fmt"
+	"go.uber.org/zap"
+	"log/slog"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+// Logger is the logging interface used by the cache, fields are passed as
+// alternating key/value pairs
+
+type Logger interface {
+	Debug(msg string, keysAndValues ...interface{})
+	Info(msg string, keysAndValues ...interface{})
+	Warn(msg string, keysAndValues ...interface{})
+	Error(msg string, keysAndValues ...interface{})
+}
+
+// zap adapter
+
+type zapLogger struct {
+	s *zap.SugaredLogger
+}
+
+func NewZapLogger(l *zap.Logger) Logger {
+	return &zapLogger{s: l.Sugar()}
+}
+
+func (z *zapLogger) Debug(msg string, kv ...interface{}) { z.s.Debugw(msg, kv...) }
+func (z *zapLogger) Info(msg string, kv ...interface{})  { z.s.Infow(msg, kv...) }
+func (z *zapLogger) Warn(msg string, kv ...interface{})  { z.s.Warnw(msg, kv...) }
+func (z *zapLogger) Error(msg string, kv ...interface{}) { z.s.Errorw(msg, kv...) }
+
+// no-op adapter, used when CacheOptions.Logger is nil
+
+type nopLogger struct{}
+
+func NewNopLogger() Logger {
+	return nopLogger{}
+}
+
+func (nopLogger) Debug(string, ...interface{}) {}
+func (nopLogger) Info(string, ...interface{})  {}
+func (nopLogger) Warn(string, ...interface{})  {}
+func (nopLogger) Error(string, ...interface{}) {}
+
+// defaultLogger returns a zap production logger, falling back to no-op if it can't be built
+func defaultLogger() Logger {
+	l, err := zap.NewProduction()
+	if err != nil {
+		return NewNopLogger()
+	}
+	return NewZapLogger(l)
+}
+
+// slog adapter
+
+type slogLogger struct {
+	l *slog.Logger
+}
+
+func NewSlogLogger(l *slog.Logger) Logger {
+	return &slogLogger{l: l}
+}
+
+func (s *slogLogger) Debug(msg string, kv ...interface{}) { s.l.Debug(msg, kv...) }
+func (s *slogLogger) Info(msg string, kv ...interface{})  { s.l.Info(msg, kv...) }
+func (s *slogLogger) Warn(msg string, kv ...interface{})  { s.l.Warn(msg, kv...) }
+func (s *slogLogger) Error(msg string, kv ...interface{}) { s.l.Error(msg, kv...) }
+
+type LogLevel int32
+
+const (
+	LevelDebug LogLevel = iota - 1
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
+func (l LogLevel) String() string {
+	switch l {
+	case LevelDebug:
+		return "debug"
+	case LevelInfo:
+		return "info"
+	case LevelWarn:
+		return "warn"
+	case LevelError:
+		return "error"
+	default:
+		return "unknown"
+	}
+}
+
+func ParseLogLevel(s string) (LogLevel, error) {
+	switch strings.ToLower(s) {
+	case "debug":
+		return LevelDebug, nil
+	case "info", "":
+		return LevelInfo, nil
+	case "warn", "warning":
+		return LevelWarn, nil
+	case "error":
+		return LevelError, nil
+	default:
+		return LevelInfo, fmt.Errorf("unknown log level %q", s)
+	}
+}
+
+// cacheLogger attaches the cache name and labels to every record and drops
+// records below its level
+
+type cacheLogger struct {
+	next   Logger
+	fields []interface{} // cache name, then labels sorted by key
+	level  int32
+}
+
+func newCacheLogger(next Logger, name string, labels map[string]string, level LogLevel) *cacheLogger {
+	if next == nil {
+		next = NewNopLogger()
+	}
+	fields := []interface{}{"cache", name}
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		fields = append(fields, k, labels[k])
+	}
+	return &cacheLogger{next: next, fields: fields, level: int32(level)}
+}
+
+func (l *cacheLogger) setLevel(level LogLevel) {
+	atomic.StoreInt32(&l.level, int32(level))
+}
+
+func (l *cacheLogger) enabled(level LogLevel) bool {
+	return LogLevel(atomic.LoadInt32(&l.level)) <= level
+}
+
+func (l *cacheLogger) with(kv []interface{}) []interface{} {
+	return append(l.fields[:len(l.fields):len(l.fields)], kv...)
+}
+
+func (l *cacheLogger) log(level LogLevel, msg string, kv ...interface{}) {
+	if !l.enabled(level) {
+		return
+	}
+	switch level {
+	case LevelDebug:
+		l.next.Debug(msg, l.with(kv)...)
+	case LevelInfo:
+		l.next.Info(msg, l.with(kv)...)
+	case LevelWarn:
+		l.next.Warn(msg, l.with(kv)...)
+	default:
+		l.next.Error(msg, l.with(kv)...)
+	}
+}
+
+func (l *cacheLogger) Debug(msg string, kv ...interface{}) { l.log(LevelDebug, msg, kv...) }
+func (l *cacheLogger) Info(msg string, kv ...interface{})  { l.log(LevelInfo, msg, kv...) }
+func (l *cacheLogger) Warn(msg string, kv ...interface{})  { l.log(LevelWarn, msg, kv...) }
+func (l *cacheLogger) Error(msg string, kv ...interface{}) { l.log(LevelError, msg, kv...) }
+
+// opLog logs a per-call record with the standard op/key_hash fields, unless
+// CacheOptions.QuietOperations is set. Keys are hashed so cached identifiers
+// don't leak into logs.
+func (c *Cache) opLog(level LogLevel, msg, op, key string, kv ...interface{}) {
+	if c.opts.QuietOperations || !c.logger.enabled(level) {
+		return
+	}
+	c.logger.log(level, msg, append([]interface{}{"op", op, "key_hash", keyHash(key)}, kv...)...)
+}
+
+func keyHash(key string) string {
+	return strconv.FormatUint(scanHash(key), 16)
+}
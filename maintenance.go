@@ -0,0 +1,52 @@
+package LCache_go
+
+import (
+	"lcache/store"
+	"sync/atomic"
+)
+
+// PauseMaintenance stops the store from expiring and evicting entries, e.g.
+// during a bulk load or while debugging, until ResumeMaintenance. As a safety
+// cap the store still evicts once usage passes PauseMaxBytes, which defaults to
+// twice MaxBytes. Stores that can't pause (see store.Pauser) return ErrNotSupported.
+func (c *Cache) PauseMaintenance() error {
+	return c.withPauser(func(p store.Pauser) {
+		limit := c.opts.PauseMaxBytes
+		if limit == 0 {
+			limit = 2 * atomic.LoadInt64(&c.maxBytes)
+		}
+		p.Pause(limit)
+		atomic.StoreInt32(&c.paused, 1)
+	})
+}
+
+// ResumeMaintenance undoes PauseMaintenance, the store then catches up on the
+// expirations and evictions it skipped
+func (c *Cache) ResumeMaintenance() error {
+	return c.withPauser(func(p store.Pauser) {
+		p.Resume()
+		atomic.StoreInt32(&c.paused, 0)
+	})
+}
+
+// MaintenancePaused reports whether PauseMaintenance is in effect
+func (c *Cache) MaintenancePaused() bool {
+	return atomic.LoadInt32(&c.paused) == 1
+}
+
+func (c *Cache) withPauser(fn func(p store.Pauser)) error {
+	if !OpenedAndInitialized(c) {
+		return ErrCacheClosed
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.store == nil {
+		return ErrCacheClosed
+	}
+	p, ok := c.store.(store.Pauser)
+	if !ok {
+		return ErrNotSupported
+	}
+	fn(p)
+	return nil
+}
@@ -0,0 +1,259 @@
+package LCache_go
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Manager creates, tracks and closes named caches, optionally keeping their
+// combined usage under a process-wide memory budget
+
+type Manager struct {
+	mu     sync.RWMutex
+	caches map[string]*Cache
+	closed bool
+
+	budget        int64
+	budgetStop    chan struct{}
+	budgetRuns    int64 // enforcement passes that had to evict
+	budgetEvicted int64 // bytes evicted to honor the budget
+}
+
+func NewManager() *Manager {
+	return &Manager{
+		caches: make(map[string]*Cache),
+	}
+}
+
+// NewManagerWithBudget returns a Manager that checks every interval whether its
+// caches use more than budget bytes in total and, if so, evicts from each cache
+// in proportion to its share of the usage
+func NewManagerWithBudget(budget int64, interval time.Duration) (*Manager, error) {
+	if budget <= 0 || interval <= 0 {
+		return nil, errors.New("lcache: budget and interval must be positive")
+	}
+	m := NewManager()
+	m.budget = budget
+	m.budgetStop = make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-m.budgetStop:
+				return
+			case <-ticker.C:
+				m.EnforceBudget()
+			}
+		}
+	}()
+	return m, nil
+}
+
+// EnforceBudget runs one budget check right away and returns the bytes evicted
+func (m *Manager) EnforceBudget() int64 {
+	if m.budget <= 0 {
+		return 0
+	}
+	m.mu.RLock()
+	caches := make([]*Cache, 0, len(m.caches))
+	for _, c := range m.caches {
+		caches = append(caches, c)
+	}
+	m.mu.RUnlock()
+
+	used := make([]int64, len(caches))
+	var total int64
+	for i, c := range caches {
+		used[i] = c.UsedBytes()
+		total += used[i]
+	}
+	excess := total - m.budget
+	if excess <= 0 {
+		return 0
+	}
+
+	var freed int64
+	for i, c := range caches {
+		if used[i] == 0 {
+			continue
+		}
+		// round up so the shares add up to at least the excess
+		share := (excess*used[i] + total - 1) / total
+		freed += c.Trim(share)
+	}
+	atomic.AddInt64(&m.budgetRuns, 1)
+	atomic.AddInt64(&m.budgetEvicted, freed)
+	return freed
+}
+
+// Create builds a cache named name from opts, failing if the name is taken
+func (m *Manager) Create(name string, opts ...Option) (*Cache, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.closed {
+		return nil, ErrCacheClosed
+	}
+	if _, ok := m.caches[name]; ok {
+		return nil, fmt.Errorf("lcache: cache %q already exists", name)
+	}
+	return m.create(name, opts)
+}
+
+// GetOrCreate returns the cache named name, creating it from opts if needed
+func (m *Manager) GetOrCreate(name string, opts ...Option) (*Cache, error) {
+	if c, ok := m.Get(name); ok {
+		return c, nil
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.closed {
+		return nil, ErrCacheClosed
+	}
+	if c, ok := m.caches[name]; ok {
+		return c, nil
+	}
+	return m.create(name, opts)
+}
+
+// create builds and registers a cache, need to hold the lock
+func (m *Manager) create(name string, opts []Option) (*Cache, error) {
+	c, err := New(append(opts, WithName(name))...)
+	if err != nil {
+		return nil, err
+	}
+	m.caches[name] = c
+	return c, nil
+}
+
+func (m *Manager) Get(name string) (*Cache, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	c, ok := m.caches[name]
+	return c, ok
+}
+
+// Remove closes the cache named name and forgets it
+func (m *Manager) Remove(name string) bool {
+	m.mu.Lock()
+	c, ok := m.caches[name]
+	delete(m.caches, name)
+	m.mu.Unlock()
+
+	if ok {
+		c.Close()
+	}
+	return ok
+}
+
+// Names returns the registered cache names in sorted order
+func (m *Manager) Names() []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	names := make([]string, 0, len(m.caches))
+	for name := range m.caches {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Stats returns totals across all caches, with per-cache Stats under "caches".
+// Each cache's Stats gain "used_share", its fraction of the total used bytes, to
+// show which cache is consuming the budget.
+func (m *Manager) Stats() Stats {
+	m.mu.RLock()
+	caches := make(map[string]*Cache, len(m.caches))
+	for name, c := range m.caches {
+		caches[name] = c
+	}
+	m.mu.RUnlock()
+
+	var hits, misses, usedBytes, maxBytes, loads, loadsDeduped int64
+	var size int
+	perCache := make(map[string]Stats, len(caches))
+	for name, c := range caches {
+		s := c.Stats()
+		perCache[name] = s
+		hits += s["hits"].(int64)
+		misses += s["misses"].(int64)
+		usedBytes += s["used_bytes"].(int64)
+		maxBytes += s["max_bytes"].(int64)
+		size += s["size"].(int)
+		loads += s["loads"].(int64)
+		loadsDeduped += s["loads_deduped"].(int64)
+	}
+	for _, s := range perCache {
+		if usedBytes > 0 {
+			s["used_share"] = float64(s["used_bytes"].(int64)) / float64(usedBytes)
+		} else {
+			s["used_share"] = 0.0
+		}
+	}
+
+	stats := Stats{
+		"count":      len(caches),
+		"hits":       hits,
+		"misses":     misses,
+		"size":       size,
+		"used_bytes": usedBytes,
+		"max_bytes":  maxBytes,
+		"caches":     perCache,
+
+		"loads":         loads,
+		"loads_deduped": loadsDeduped,
+
+		"memory_budget":        m.budget,
+		"budget_runs":          atomic.LoadInt64(&m.budgetRuns),
+		"budget_evicted_bytes": atomic.LoadInt64(&m.budgetEvicted),
+	}
+	if total := hits + misses; total > 0 {
+		stats["hit_rate"] = float64(hits) / float64(total)
+	} else {
+		stats["hit_rate"] = 0.0
+	}
+	return stats
+}
+
+// Close closes every cache; the manager refuses new caches afterwards
+func (m *Manager) Close() {
+	for _, c := range m.takeAll() {
+		c.Close()
+	}
+}
+
+// CloseContext gracefully closes every cache, see Cache.CloseContext
+func (m *Manager) CloseContext(ctx context.Context) error {
+	var errs []error
+	for _, c := range m.takeAll() {
+		if err := c.CloseContext(ctx); err != nil && err != ErrCacheClosed {
+			errs = append(errs, fmt.Errorf("%s: %w", c.opts.Name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (m *Manager) takeAll() []*Cache {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if !m.closed && m.budgetStop != nil {
+		close(m.budgetStop)
+	}
+	m.closed = true
+	caches := make([]*Cache, 0, len(m.caches))
+	for _, c := range m.caches {
+		caches = append(caches, c)
+	}
+	m.caches = make(map[string]*Cache)
+	return caches
+}
@@ -0,0 +1,134 @@
+package LCache_go
+
+import (
+	"context"
+	"fmt"
+	"lcache/store"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// NamespaceSeparator joins a namespace prefix and a key
+const NamespaceSeparator = ":"
+
+// NamespacedCache is a view of a Cache whose keys are transparently prefixed,
+// so several modules can share one cache without key collisions
+
+type NamespacedCache struct {
+	cache  *Cache
+	prefix string // includes the trailing separator
+}
+
+// Namespace returns a view of c storing keys as prefix + NamespaceSeparator + key
+func (c *Cache) Namespace(prefix string) *NamespacedCache {
+	return &NamespacedCache{cache: c, prefix: prefix + NamespaceSeparator}
+}
+
+// Namespace returns a nested namespace
+func (n *NamespacedCache) Namespace(prefix string) *NamespacedCache {
+	return &NamespacedCache{cache: n.cache, prefix: n.prefix + prefix + NamespaceSeparator}
+}
+
+// Prefix returns the prefix added to every key, separator included
+func (n *NamespacedCache) Prefix() string {
+	return n.prefix
+}
+
+func (n *NamespacedCache) key(key string) string {
+	return n.prefix + key
+}
+
+func (n *NamespacedCache) Get(key string) (ByteView, bool) {
+	bv, err := n.GetCtx(context.Background(), key)
+	return bv, err == nil
+}
+
+func (n *NamespacedCache) Lookup(key string) (ByteView, error) {
+	if err := n.allow(); err != nil {
+		return ByteView{}, err
+	}
+	return n.cache.Lookup(n.key(key))
+}
+
+func (n *NamespacedCache) GetCtx(ctx context.Context, key string) (ByteView, error) {
+	if err := n.allow(); err != nil {
+		return ByteView{}, err
+	}
+	return n.cache.GetCtx(ctx, n.key(key))
+}
+
+func (n *NamespacedCache) Set(key string, value ByteView) error {
+	if err := n.allow(); err != nil {
+		return err
+	}
+	return n.cache.Set(n.key(key), value)
+}
+
+func (n *NamespacedCache) SetWithTTL(key string, value ByteView, ttl time.Duration) error {
+	if err := n.allow(); err != nil {
+		return err
+	}
+	return n.cache.SetWithTTL(n.key(key), value, ttl)
+}
+
+func (n *NamespacedCache) SetCtx(ctx context.Context, key string, value ByteView, ttl time.Duration) error {
+	if err := n.allow(); err != nil {
+		return err
+	}
+	return n.cache.SetCtx(ctx, n.key(key), value, ttl)
+}
+
+func (n *NamespacedCache) Delete(key string) bool {
+	return n.cache.Delete(n.key(key))
+}
+
+func (n *NamespacedCache) Remove(key string) error {
+	return n.cache.Remove(n.key(key))
+}
+
+func (n *NamespacedCache) DeleteCtx(ctx context.Context, key string) error {
+	return n.cache.DeleteCtx(ctx, n.key(key))
+}
+
+// Has reports whether key is cached in the namespace
+func (n *NamespacedCache) Has(key string) bool {
+	return n.cache.Has(n.key(key))
+}
+
+// Len counts the entries in the namespace
+func (n *NamespacedCache) Len() int {
+	return n.cache.CountPrefix(n.prefix)
+}
+
+// ClearNamespace deletes every entry in the namespace and returns how many were
+// removed. With FingerprintKeys it fails with ErrNotSupported, stored keys no
+// longer carry the prefix.
+func (n *NamespacedCache) ClearNamespace() (int, error) {
+	if n.cache.opts.FingerprintKeys {
+		return 0, fmt.Errorf("%w: ClearNamespace with FingerprintKeys", ErrNotSupported)
+	}
+	var keys []string
+	n.cache.rangeEntries(func(key string, _ store.Value, _ time.Time) bool {
+		if strings.HasPrefix(key, n.prefix) {
+			keys = append(keys, key)
+		}
+		return true
+	})
+
+	removed := n.cache.removeStored(keys, nil)
+	n.cache.logger.Info("Namespace cleared", "namespace", n.prefix, "removed", removed)
+	return removed, nil
+}
+
+// rangeEntries iterates the store under the cache read lock, see store.Store.Range
+func (c *Cache) rangeEntries(fn func(key string, value store.Value, expiresAt time.Time) bool) {
+	if atomic.LoadInt32(&c.closed) == 1 || atomic.LoadInt32(&c.initialized) == 0 {
+		return
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.store != nil {
+		c.store.Range(fn)
+	}
+}
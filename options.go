@@ -0,0 +1,209 @@
+package LCache_go
+
+import (
+	"lcache/store"
+	"time"
+)
+
+// Option configures a cache built with New
+
+type Option func(*CacheOptions)
+
+// New builds a cache from DefaultCacheOptions with opts applied in order
+func New(opts ...Option) (*Cache, error) {
+	o := DefaultCacheOptions()
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return NewCache(o)
+}
+
+func WithName(name string) Option {
+	return func(o *CacheOptions) { o.Name = name }
+}
+
+// WithLabels adds labels, later calls override earlier ones for the same key
+func WithLabels(labels map[string]string) Option {
+	return func(o *CacheOptions) {
+		if o.Labels == nil {
+			o.Labels = make(map[string]string, len(labels))
+		}
+		for k, v := range labels {
+			o.Labels[k] = v
+		}
+	}
+}
+
+func WithCacheType(t store.CacheType) Option {
+	return func(o *CacheOptions) { o.CacheType = t }
+}
+
+func WithMaxBytes(n int64) Option {
+	return func(o *CacheOptions) { o.MaxBytes = n }
+}
+
+func WithMaxEntryBytes(n int64) Option {
+	return func(o *CacheOptions) { o.MaxEntryBytes = n }
+}
+
+func WithCleanupInterval(d time.Duration) Option {
+	return func(o *CacheOptions) { o.CleanupTime = d }
+}
+
+func WithAdaptiveCleanup() Option {
+	return func(o *CacheOptions) { o.AdaptiveCleanup = true }
+}
+
+func WithEvictionBatch(n int) Option {
+	return func(o *CacheOptions) { o.EvictionBatch = n }
+}
+
+// WithAsyncEviction moves eviction to a background goroutine, zero watermarks keep the defaults
+func WithAsyncEviction(high, low float64) Option {
+	return func(o *CacheOptions) {
+		o.AsyncEviction = true
+		o.HighWatermark = high
+		o.LowWatermark = low
+	}
+}
+
+func WithDefaultTTL(ttl time.Duration) Option {
+	return func(o *CacheOptions) { o.DefaultTTL = ttl }
+}
+
+func WithOnEvicted(fn func(key string, value store.Value)) Option {
+	return func(o *CacheOptions) { o.OnEvicted = fn }
+}
+
+// WithOnExpired calls fn for entries removed because their TTL lapsed
+func WithOnExpired(fn func(key string, value store.Value)) Option {
+	return func(o *CacheOptions) { o.OnExpired = fn }
+}
+
+func WithLoader(loader LoaderFunc) Option {
+	return func(o *CacheOptions) { o.Loader = loader }
+}
+
+// WithBatchLoader coalesces misses arriving within window into calls of at most
+// maxBatch keys, see CacheOptions.BatchLoader
+func WithBatchLoader(loader BatchLoaderFunc, window time.Duration, maxBatch int) Option {
+	return func(o *CacheOptions) {
+		o.BatchLoader = loader
+		o.BatchWindow = window
+		o.MaxBatchSize = maxBatch
+	}
+}
+
+func WithSetTimeout(d time.Duration) Option {
+	return func(o *CacheOptions) { o.SetTimeout = d }
+}
+
+func WithDeleteTimeout(d time.Duration) Option {
+	return func(o *CacheOptions) { o.DeleteTimeout = d }
+}
+
+// WithLoadShedding applies policy to new loads once max loads are running
+func WithLoadShedding(max int, policy ShedPolicy) Option {
+	return func(o *CacheOptions) {
+		o.MaxConcurrentLoads = max
+		o.LoadShedding = policy
+	}
+}
+
+func WithLoaderBreaker(opts BreakerOptions) Option {
+	return func(o *CacheOptions) { o.LoaderBreaker = &opts }
+}
+
+func WithLoaderRetry(p RetryPolicy) Option {
+	return func(o *CacheOptions) { o.LoaderRetry = &p }
+}
+
+// WithFallbackLoaders tries loaders in order on a miss, see CacheOptions.FallbackLoaders
+func WithFallbackLoaders(loaders ...FallbackLoader) Option {
+	return func(o *CacheOptions) { o.FallbackLoaders = loaders }
+}
+
+func WithKeyTransform(fn func(key string) string) Option {
+	return func(o *CacheOptions) { o.KeyTransform = fn }
+}
+
+// WithValueCodec sets EncodeValue and DecodeValue
+func WithValueCodec(encode, decode func(key string, value []byte) ([]byte, error)) Option {
+	return func(o *CacheOptions) {
+		o.EncodeValue = encode
+		o.DecodeValue = decode
+	}
+}
+
+// WithEncryptionKey keeps values AES-GCM encrypted in memory under key
+func WithEncryptionKey(key []byte) Option {
+	return func(o *CacheOptions) { o.EncryptionKey = key }
+}
+
+func WithVerifyChecksums() Option {
+	return func(o *CacheOptions) { o.VerifyChecksums = true }
+}
+
+// WithTrackWriters records the label of each key's last writer, see CacheOptions.TrackWriters
+func WithTrackWriters() Option {
+	return func(o *CacheOptions) { o.TrackWriters = true }
+}
+
+func WithFingerprintKeys() Option {
+	return func(o *CacheOptions) { o.FingerprintKeys = true }
+}
+
+// WithRules appends rules, see CacheOptions.Rules
+func WithRules(rules ...Rule) Option {
+	return func(o *CacheOptions) { o.Rules = append(o.Rules, rules...) }
+}
+
+func WithAbsentFilter(opts AbsentFilterOptions) Option {
+	return func(o *CacheOptions) { o.AbsentFilter = &opts }
+}
+
+// WithStoreFaults injects failures into cache reads, writes and deletes, for tests only
+func WithStoreFaults(opts FaultOptions) Option {
+	return func(o *CacheOptions) { o.StoreFaults = &opts }
+}
+
+// WithLoaderFaults injects failures into loader calls, for tests only
+func WithLoaderFaults(opts FaultOptions) Option {
+	return func(o *CacheOptions) { o.LoaderFaults = &opts }
+}
+
+// WithShadow evaluates another CacheType or size on the cache's traffic, see CacheOptions.Shadow
+func WithShadow(opts ShadowOptions) Option {
+	return func(o *CacheOptions) { o.Shadow = &opts }
+}
+
+func WithLoaderTimeout(d time.Duration) Option {
+	return func(o *CacheOptions) { o.LoaderTimeout = d }
+}
+
+func WithSnapshotPath(path string) Option {
+	return func(o *CacheOptions) { o.SnapshotPath = path }
+}
+
+func WithStatsReporter(fn func(Stats), interval time.Duration) Option {
+	return func(o *CacheOptions) {
+		o.StatsReporter = fn
+		o.StatsInterval = interval
+	}
+}
+
+func WithLogger(l Logger) Option {
+	return func(o *CacheOptions) { o.Logger = l }
+}
+
+func WithLogLevel(level LogLevel) Option {
+	return func(o *CacheOptions) { o.LogLevel = level }
+}
+
+func WithQuietOperations() Option {
+	return func(o *CacheOptions) { o.QuietOperations = true }
+}
+
+func WithTrackTopKeys(n int) Option {
+	return func(o *CacheOptions) { o.TrackTopKeys = n }
+}
@@ -0,0 +1,104 @@
+package LCache_go
+
+import (
+	"lcache/store"
+	"strings"
+	"time"
+)
+
+// MatchPattern reports whether key matches a redis-style glob: * matches any run of
+// characters (including '/'), ? a single character, [abc] or [a-z] a class, and
+// \ escapes the next character
+func MatchPattern(pattern, key string) bool {
+	for len(pattern) > 0 {
+		switch pattern[0] {
+		case '*':
+			for len(pattern) > 0 && pattern[0] == '*' {
+				pattern = pattern[1:]
+			}
+			if pattern == "" {
+				return true
+			}
+			for i := 0; i <= len(key); i++ {
+				if MatchPattern(pattern, key[i:]) {
+					return true
+				}
+			}
+			return false
+		case '?':
+			if key == "" {
+				return false
+			}
+			pattern, key = pattern[1:], key[1:]
+		case '[':
+			if key == "" {
+				return false
+			}
+			end := strings.IndexByte(pattern[1:], ']')
+			if end < 0 {
+				return pattern == key
+			}
+			class := pattern[1 : end+1]
+			if !matchClass(class, key[0]) {
+				return false
+			}
+			pattern, key = pattern[end+2:], key[1:]
+		default:
+			if pattern[0] == '\\' && len(pattern) > 1 {
+				pattern = pattern[1:]
+			}
+			if key == "" || pattern[0] != key[0] {
+				return false
+			}
+			pattern, key = pattern[1:], key[1:]
+		}
+	}
+	return key == ""
+}
+
+func matchClass(class string, c byte) bool {
+	negate := strings.HasPrefix(class, "^")
+	if negate {
+		class = class[1:]
+	}
+	matched := false
+	for i := 0; i < len(class); i++ {
+		if i+2 < len(class) && class[i+1] == '-' {
+			if class[i] <= c && c <= class[i+2] {
+				matched = true
+			}
+			i += 2
+		} else if class[i] == c {
+			matched = true
+		}
+	}
+	return matched != negate
+}
+
+// PatternPrefix returns the literal prefix of pattern before its first wildcard
+func PatternPrefix(pattern string) string {
+	if i := strings.IndexAny(pattern, "*?[\\"); i >= 0 {
+		return pattern[:i]
+	}
+	return pattern
+}
+
+// FlushPattern deletes every key matching the glob pattern and returns how many
+// were removed. With dryRun it only counts the matches.
+func (c *Cache) FlushPattern(pattern string, dryRun bool) int {
+	prefix := PatternPrefix(pattern)
+	var keys []string
+	c.rangeEntries(func(key string, _ store.Value, _ time.Time) bool {
+		if strings.HasPrefix(key, prefix) && MatchPattern(pattern, key) {
+			keys = append(keys, key)
+		}
+		return true
+	})
+	if dryRun {
+		return len(keys)
+	}
+
+	removed := c.removeStored(keys, nil)
+	c.logger.Info("Pattern flushed", "pattern", pattern, "removed", removed)
+	return removed
+}
@@ -0,0 +1,79 @@
+package LCache_go
+
+import (
+	"fmt"
+	"lcache/store"
+	"sync/atomic"
+)
+
+// SetQuota caps the bytes and entries of keys starting with prefix. Sets that would
+// exceed it fail with ErrQuotaExceeded. With FingerprintKeys it fails with
+// ErrNotSupported, as stored keys no longer carry the prefix.
+func (c *Cache) SetQuota(prefix string, q store.Quota) error {
+	if c.opts.FingerprintKeys {
+		return fmt.Errorf("%w: quotas with FingerprintKeys", ErrNotSupported)
+	}
+	qs, err := c.quotaStore()
+	if err != nil {
+		return err
+	}
+	qs.SetQuota(prefix, q)
+	c.logger.Info("Quota set", "prefix", prefix, "maxBytes", q.MaxBytes, "maxEntries", q.MaxEntries)
+	return nil
+}
+
+func (c *Cache) RemoveQuota(prefix string) error {
+	qs, err := c.quotaStore()
+	if err != nil {
+		return err
+	}
+	qs.RemoveQuota(prefix)
+	return nil
+}
+
+// QuotaUsage returns usage and rejection counts keyed by quota prefix
+func (c *Cache) QuotaUsage() map[string]store.QuotaUsage {
+	qs, err := c.quotaStore()
+	if err != nil {
+		return nil
+	}
+	return qs.QuotaUsage()
+}
+
+func (c *Cache) quotaStore() (store.QuotaStore, error) {
+	if !OpenedAndInitialized(c) {
+		return nil, ErrCacheClosed
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.store == nil {
+		return nil, ErrCacheClosed
+	}
+	qs, ok := c.store.(store.QuotaStore)
+	if !ok {
+		return nil, ErrNotSupported
+	}
+	return qs, nil
+}
+
+// SetQuota caps the namespace, see Cache.SetQuota
+func (n *NamespacedCache) SetQuota(maxBytes int64, maxEntries int) error {
+	return n.cache.SetQuota(n.prefix, store.Quota{MaxBytes: maxBytes, MaxEntries: maxEntries})
+}
+
+func (n *NamespacedCache) RemoveQuota() error {
+	return n.cache.RemoveQuota(n.prefix)
+}
+
+// QuotaUsage reports the namespace usage, ok is false when it has no quota
+func (n *NamespacedCache) QuotaUsage() (store.QuotaUsage, bool) {
+	u, ok := n.cache.QuotaUsage()[n.prefix]
+	return u, ok
+}
+
+func (c *Cache) quotaStats() map[string]store.QuotaUsage {
+	if atomic.LoadInt32(&c.closed) == 1 || atomic.LoadInt32(&c.initialized) == 0 {
+		return nil
+	}
+	return c.QuotaUsage()
+}
@@ -0,0 +1,106 @@
+package LCache_go
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// token bucket refilled continuously at rate tokens per second up to burst
+
+type tokenBucket struct {
+	mu        sync.Mutex
+	rate      float64
+	burst     float64
+	tokens    float64
+	last      time.Time
+	throttled int64
+}
+
+func newTokenBucket(rate float64, burst int) *tokenBucket {
+	return &tokenBucket{
+		rate:   rate,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+func (b *tokenBucket) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	b.tokens += now.Sub(b.last).Seconds() * b.rate
+	if b.tokens > b.burst {
+		b.tokens = b.burst
+	}
+	b.last = now
+	if b.tokens < 1 {
+		atomic.AddInt64(&b.throttled, 1)
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// per-prefix limiters of a cache
+
+type rateLimits struct {
+	mu      sync.RWMutex
+	buckets map[string]*tokenBucket
+}
+
+func (r *rateLimits) set(prefix string, b *tokenBucket) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.buckets == nil {
+		r.buckets = make(map[string]*tokenBucket)
+	}
+	if b == nil {
+		delete(r.buckets, prefix)
+	} else {
+		r.buckets[prefix] = b
+	}
+}
+
+func (r *rateLimits) allow(prefix string) bool {
+	r.mu.RLock()
+	b := r.buckets[prefix]
+	r.mu.RUnlock()
+	return b == nil || b.allow()
+}
+
+// throttled returns the number of rejected calls per prefix
+func (r *rateLimits) throttled() map[string]int64 {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	counts := make(map[string]int64, len(r.buckets))
+	for prefix, b := range r.buckets {
+		counts[prefix] = atomic.LoadInt64(&b.throttled)
+	}
+	return counts
+}
+
+// SetRateLimit limits Get and Set calls through this namespace to ratePerSecond,
+// allowing bursts of up to burst calls. Throttled calls fail with ErrRateLimited.
+func (n *NamespacedCache) SetRateLimit(ratePerSecond float64, burst int) error {
+	if ratePerSecond <= 0 || burst < 1 {
+		return fmt.Errorf("lcache: invalid rate limit %v/s burst %d", ratePerSecond, burst)
+	}
+	n.cache.limits.set(n.prefix, newTokenBucket(ratePerSecond, burst))
+	return nil
+}
+
+func (n *NamespacedCache) RemoveRateLimit() {
+	n.cache.limits.set(n.prefix, nil)
+}
+
+func (n *NamespacedCache) allow() error {
+	if !n.cache.limits.allow(n.prefix) {
+		return ErrRateLimited
+	}
+	return nil
+}
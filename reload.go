@@ -0,0 +1,186 @@
+package LCache_go
+
+import (
+	"bytes"
+	"fmt"
+	"lcache/store"
+	"os"
+	"os/signal"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+// Resize changes MaxBytes of a live cache, evicting right away if it shrank
+func (c *Cache) Resize(maxBytes int64) error {
+	if maxBytes < 0 {
+		return fmt.Errorf("lcache: MaxBytes must not be negative, got %d", maxBytes)
+	}
+
+	c.mu.Lock()
+	if c.opts.MaxEntryBytes > 0 && maxBytes > 0 && c.opts.MaxEntryBytes > maxBytes {
+		c.mu.Unlock()
+		return fmt.Errorf("lcache: MaxEntryBytes %d exceeds MaxBytes %d", c.opts.MaxEntryBytes, maxBytes)
+	}
+	c.opts.MaxBytes = maxBytes
+	atomic.StoreInt64(&c.maxBytes, maxBytes)
+	c.mu.Unlock()
+
+	// shrinking evicts, so only hold the read lock to let other readers through
+	c.mu.RLock()
+	if c.store != nil {
+		c.store.SetMaxBytes(maxBytes)
+	}
+	c.mu.RUnlock()
+	c.logger.Info("Cache resized", "maxBytes", maxBytes)
+	return nil
+}
+
+// Trim evicts least recently used entries until at least bytes have been freed,
+// returning the bytes actually freed
+func (c *Cache) Trim(bytes int64) int64 {
+	if atomic.LoadInt32(&c.closed) == 1 || atomic.LoadInt32(&c.initialized) == 0 {
+		return 0
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.store == nil {
+		return 0
+	}
+	return c.store.Trim(bytes)
+}
+
+// SetDefaultTTL changes the ttl applied to values stored without one
+func (c *Cache) SetDefaultTTL(ttl time.Duration) error {
+	if ttl < 0 {
+		return fmt.Errorf("lcache: DefaultTTL must not be negative, got %v", ttl)
+	}
+	atomic.StoreInt64(&c.defaultTTL, int64(ttl))
+	return nil
+}
+
+// ApplyOptions applies the runtime-safe settings of opts (MaxBytes, DefaultTTL,
+// LogLevel) to a live cache. It fails without changing anything if opts is invalid
+// or differs in a setting that needs a new cache, such as CacheType.
+func (c *Cache) ApplyOptions(opts CacheOptions) error {
+	if err := opts.Validate(); err != nil {
+		return err
+	}
+	// an empty CacheType means LRU
+	from, to := c.opts.CacheType, opts.CacheType
+	if from == "" {
+		from = store.LRU
+	}
+	if to == "" {
+		to = store.LRU
+	}
+	if from != to {
+		return fmt.Errorf("lcache: changing CacheType from %q to %q requires a new cache", from, to)
+	}
+	if opts.CleanupTime != c.opts.CleanupTime {
+		return fmt.Errorf("lcache: changing CleanupTime requires a new cache")
+	}
+	if opts.AdaptiveCleanup != c.opts.AdaptiveCleanup {
+		return fmt.Errorf("lcache: changing AdaptiveCleanup requires a new cache")
+	}
+	if opts.MaxEntryBytes != c.opts.MaxEntryBytes {
+		return fmt.Errorf("lcache: changing MaxEntryBytes requires a new cache")
+	}
+	if !bytes.Equal(opts.EncryptionKey, c.opts.EncryptionKey) {
+		return fmt.Errorf("lcache: changing EncryptionKey requires a new cache")
+	}
+	if opts.VerifyChecksums != c.opts.VerifyChecksums {
+		return fmt.Errorf("lcache: changing VerifyChecksums requires a new cache")
+	}
+	if opts.FingerprintKeys != c.opts.FingerprintKeys {
+		return fmt.Errorf("lcache: changing FingerprintKeys requires a new cache")
+	}
+	if opts.AsyncEviction != c.opts.AsyncEviction || opts.EvictionBatch != c.opts.EvictionBatch ||
+		opts.HighWatermark != c.opts.HighWatermark || opts.LowWatermark != c.opts.LowWatermark {
+		return fmt.Errorf("lcache: changing AsyncEviction, EvictionBatch or the watermarks requires a new cache")
+	}
+
+	if opts.MaxBytes != atomic.LoadInt64(&c.maxBytes) {
+		if err := c.Resize(opts.MaxBytes); err != nil {
+			return err
+		}
+	}
+	if err := c.SetDefaultTTL(opts.DefaultTTL); err != nil {
+		return err
+	}
+	c.SetLogLevel(opts.LogLevel)
+	return nil
+}
+
+// ConfigWatcher reloads a config file into a live cache whenever the file changes
+// on disk or the process receives SIGHUP
+
+type ConfigWatcher struct {
+	cache    *Cache
+	path     string
+	modTime  time.Time
+	stopCh   chan struct{}
+	stopOnce sync.Once
+}
+
+// WatchConfig starts watching path, checking its modification time every interval
+func WatchConfig(c *Cache, path string, interval time.Duration) (*ConfigWatcher, error) {
+	if interval <= 0 {
+		return nil, fmt.Errorf("lcache: watch interval must be positive")
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	w := &ConfigWatcher{
+		cache:   c,
+		path:    path,
+		modTime: info.ModTime(),
+		stopCh:  make(chan struct{}),
+	}
+	go w.run(interval)
+	return w, nil
+}
+
+func (w *ConfigWatcher) run(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	for {
+		select {
+		case <-w.stopCh:
+			return
+		case <-hup:
+			w.Reload()
+		case <-ticker.C:
+			info, err := os.Stat(w.path)
+			if err != nil || !info.ModTime().After(w.modTime) {
+				continue
+			}
+			w.modTime = info.ModTime()
+			w.Reload()
+		}
+	}
+}
+
+// Reload reads the config file and applies it to the cache
+func (w *ConfigWatcher) Reload() error {
+	opts, err := LoadConfig(w.path)
+	if err == nil {
+		err = w.cache.ApplyOptions(opts)
+	}
+	if err != nil {
+		w.cache.logger.Warn("Config reload rejected", "path", w.path, "error", err)
+		return err
+	}
+	w.cache.logger.Info("Config reloaded", "path", w.path)
+	return nil
+}
+
+func (w *ConfigWatcher) Stop() {
+	w.stopOnce.Do(func() { close(w.stopCh) })
+}
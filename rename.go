@@ -0,0 +1,52 @@
+package LCache_go
+
+import (
+	"errors"
+	"fmt"
+	"lcache/store"
+	"sync/atomic"
+	"time"
+)
+
+// Rename moves the entry at oldKey to newKey in one step, keeping its value,
+// expiration and recency. It returns ErrKeyNotFound if oldKey is missing and
+// ErrKeyExists if newKey is taken and overwrite is false. With EncryptionKey
+// set it fails with ErrNotSupported, values are sealed to their key and
+// wouldn't open under the new one.
+func (c *Cache) Rename(oldKey, newKey string, overwrite bool) error {
+	defer c.latency.observe(OpSet, time.Now())
+	if !OpenedAndInitialized(c) {
+		return ErrCacheClosed
+	}
+	if c.cipher != nil {
+		return fmt.Errorf("%w: rename of encrypted values", ErrNotSupported)
+	}
+	atomic.AddInt64(&c.inflight, 1)
+	defer atomic.AddInt64(&c.inflight, -1)
+	if atomic.LoadInt32(&c.closed) == 1 {
+		return ErrCacheClosed
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.store == nil {
+		return ErrCacheClosed
+	}
+	r, ok := c.store.(store.Renamer)
+	if !ok {
+		return ErrNotSupported
+	}
+	if err := c.checkTombstone(newKey, c.storeKey(newKey)); err != nil {
+		return err
+	}
+	switch err := r.Rename(c.storeKey(oldKey), c.storeKey(newKey), overwrite); {
+	case err == nil:
+		return nil
+	case errors.Is(err, store.ErrNotFound):
+		return ErrKeyNotFound
+	case errors.Is(err, ErrKeyExists):
+		return ErrKeyExists
+	default:
+		return fmt.Errorf("lcache: rename %q to %q: %w", oldKey, newKey, err)
+	}
+}
@@ -0,0 +1,221 @@
+package LCache_go
+
+import (
+	"fmt"
+	"lcache/store"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// reportPrefixes is how many key families the keyspace section lists
+const reportPrefixes = 10
+
+// snapshotStatus remembers the outcome of the last snapshot save and restore
+// for Report
+
+type snapshotStatus struct {
+	mu          sync.Mutex
+	saved       time.Time
+	saveErr     error
+	restored    time.Time
+	restoreErr  error
+	restoreSeen bool
+}
+
+func (s *snapshotStatus) recordSave(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.saved, s.saveErr = time.Now(), err
+}
+
+func (s *snapshotStatus) recordRestore(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.restored, s.restoreErr, s.restoreSeen = time.Now(), err, true
+}
+
+// Report returns a human-readable diagnostic report in the style of Redis INFO:
+// identity and state, configuration, statistics, latency, keyspace composition
+// by key family, top keys and persistence status. It walks the whole store once,
+// so it is meant for incident tickets and signal dumps rather than polling.
+func (c *Cache) Report() string {
+	var b strings.Builder
+	section := func(name string) {
+		if b.Len() > 0 {
+			b.WriteString("\n")
+		}
+		b.WriteString("# " + name + "\n")
+	}
+	line := func(name string, v interface{}) {
+		fmt.Fprintf(&b, "%s:%v\n", name, v)
+	}
+
+	section("Cache")
+	line("name", c.opts.Name)
+	if len(c.opts.Labels) > 0 {
+		labels := make([]string, 0, len(c.opts.Labels))
+		for k, v := range c.opts.Labels {
+			labels = append(labels, k+"="+v)
+		}
+		sort.Strings(labels)
+		line("labels", strings.Join(labels, ","))
+	}
+	line("state", c.state())
+	line("cache_type", c.opts.CacheType)
+	line("capabilities", capabilityList(c.Capabilities()))
+	line("report_time", time.Now().Format(time.RFC3339))
+
+	section("Config")
+	line("max_bytes", atomic.LoadInt64(&c.maxBytes))
+	line("max_entry_bytes", c.opts.MaxEntryBytes)
+	line("default_ttl", time.Duration(atomic.LoadInt64(&c.defaultTTL)))
+	line("cleanup_interval", c.opts.CleanupTime)
+	line("adaptive_cleanup", c.opts.AdaptiveCleanup)
+	line("eviction_batch", c.opts.EvictionBatch)
+	line("async_eviction", c.opts.AsyncEviction)
+	line("loader", c.hasLoader())
+	line("rules", len(c.opts.Rules))
+	line("fingerprint_keys", c.opts.FingerprintKeys)
+	line("encrypted", c.cipher != nil)
+	line("verify_checksums", c.opts.VerifyChecksums)
+
+	section("Stats")
+	stats := c.Stats()
+	names := make([]string, 0, len(stats))
+	for name, v := range stats {
+		switch v.(type) {
+		case map[string]interface{}, map[string]string, []HistogramBucket:
+			continue
+		}
+		if name == "name" {
+			continue
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		line(name, stats[name])
+	}
+
+	section("Latency")
+	for _, op := range []string{OpGet, OpSet, OpDelete} {
+		l := c.Latency(op)
+		line(op, fmt.Sprintf("count=%d p50=%v p95=%v p99=%v max=%v", l.Count, l.P50, l.P95, l.P99, l.Max))
+	}
+
+	section("Keyspace")
+	c.reportKeyspace(line)
+
+	if top := c.TopKeys(10); len(top) > 0 {
+		section("TopKeys")
+		for i, k := range top {
+			line(fmt.Sprintf("top%d", i+1), fmt.Sprintf("key=%q count=%d", k.Key, k.Count))
+		}
+	}
+
+	section("Persistence")
+	line("snapshot_path", c.opts.SnapshotPath)
+	c.snapshots.mu.Lock()
+	if !c.snapshots.saved.IsZero() {
+		line("last_save", c.snapshots.saved.Format(time.RFC3339))
+		line("last_save_status", statusOf(c.snapshots.saveErr))
+	}
+	if c.snapshots.restoreSeen {
+		line("last_restore", c.snapshots.restored.Format(time.RFC3339))
+		line("last_restore_status", statusOf(c.snapshots.restoreErr))
+	}
+	c.snapshots.mu.Unlock()
+	return b.String()
+}
+
+// state is one of open, draining, paused or closed
+func (c *Cache) state() string {
+	switch {
+	case atomic.LoadInt32(&c.closed) == 1:
+		return "closed"
+	case atomic.LoadInt32(&c.draining) == 1:
+		return "draining"
+	case c.MaintenancePaused():
+		return "paused"
+	}
+	return "open"
+}
+
+// reportKeyspace writes entry counts and the largest key families, a family
+// being everything up to the first ':' (or the whole key without one)
+func (c *Cache) reportKeyspace(line func(string, interface{})) {
+	type family struct {
+		name          string
+		keys, expires int
+		bytes         int64
+	}
+	families := make(map[string]*family)
+	var keys, expiring int
+	c.rangeEntries(func(key string, value store.Value, expiresAt time.Time) bool {
+		name := key
+		if i := strings.IndexByte(key, ':'); i >= 0 {
+			name = key[:i+1]
+		}
+		f := families[name]
+		if f == nil {
+			f = &family{name: name}
+			families[name] = f
+		}
+		f.keys++
+		f.bytes += int64(value.Len())
+		keys++
+		if !expiresAt.IsZero() {
+			f.expires++
+			expiring++
+		}
+		return true
+	})
+	line("keys", keys)
+	line("expiring", expiring)
+	line("families", len(families))
+
+	sorted := make([]*family, 0, len(families))
+	for _, f := range families {
+		sorted = append(sorted, f)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].bytes != sorted[j].bytes {
+			return sorted[i].bytes > sorted[j].bytes
+		}
+		return sorted[i].name < sorted[j].name
+	})
+	for i, f := range sorted {
+		if i == reportPrefixes {
+			break
+		}
+		line(fmt.Sprintf("family%d", i+1), fmt.Sprintf("prefix=%q keys=%d expiring=%d bytes=%d", f.name, f.keys, f.expires, f.bytes))
+	}
+}
+
+func capabilityList(caps store.Capabilities) string {
+	var names []string
+	for _, cap := range []struct {
+		name string
+		ok   bool
+	}{
+		{"ttl", caps.TTL}, {"tags", caps.Tags}, {"iteration", caps.Iteration},
+		{"persistent", caps.Persistent}, {"events", caps.Events}, {"versions", caps.Versions},
+		{"transactions", caps.Transactions}, {"quotas", caps.Quotas}, {"pinning", caps.Pinning},
+		{"pausing", caps.Pausing}, {"renaming", caps.Renaming},
+	} {
+		if cap.ok {
+			names = append(names, cap.name)
+		}
+	}
+	return strings.Join(names, ",")
+}
+
+func statusOf(err error) string {
+	if err != nil {
+		return "error: " + err.Error()
+	}
+	return "ok"
+}
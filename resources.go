@@ -0,0 +1,126 @@
+package LCache_go
+
+import (
+	"lcache/store"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// kinds of resources a cache owns
+const (
+	resGoroutine = "goroutines"
+	resTicker    = "tickers"
+	resFile      = "open_files"
+)
+
+// leakGrace is how long background work gets to wind down after Close before
+// checkLeaks reports what is still running
+const leakGrace = time.Second
+
+// resourceTracker counts the goroutines, tickers and open files of a cache by
+// kind and by the subsystem owning them
+
+type resourceTracker struct {
+	mu     sync.Mutex
+	counts map[resourceKey]int64
+}
+
+type resourceKey struct {
+	kind  string
+	owner string
+}
+
+// acquire counts one resource of kind for owner and returns the func releasing it
+func (r *resourceTracker) acquire(kind, owner string) func() {
+	r.add(resourceKey{kind, owner}, 1)
+	return func() { r.add(resourceKey{kind, owner}, -1) }
+}
+
+func (r *resourceTracker) add(k resourceKey, n int64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.counts == nil {
+		r.counts = make(map[resourceKey]int64)
+	}
+	r.counts[k] += n
+	if r.counts[k] == 0 {
+		delete(r.counts, k)
+	}
+}
+
+// snapshot returns owner counts by kind, leaving out what isn't held
+func (r *resourceTracker) snapshot() map[string]map[string]int64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	out := make(map[string]map[string]int64)
+	for k, n := range r.counts {
+		if out[k.kind] == nil {
+			out[k.kind] = make(map[string]int64)
+		}
+		out[k.kind][k.owner] = n
+	}
+	return out
+}
+
+// Resources returns the goroutines, tickers and open files the cache currently
+// owns, by kind ("goroutines", "tickers", "open_files") and owning subsystem,
+// e.g. resources["goroutines"]["loader"]. The store's own background work is
+// listed under "store".
+func (c *Cache) Resources() map[string]map[string]int64 {
+	res := c.res.snapshot()
+	counters := c.storeCounters()
+	addStoreResources(res, counters)
+	return res
+}
+
+// addStoreResources adds the store_goroutines and store_tickers counters of a
+// store.Reporter to res under the "store" owner
+func addStoreResources(res map[string]map[string]int64, counters map[string]int64) {
+	for kind, counter := range map[string]string{resGoroutine: "store_goroutines", resTicker: "store_tickers"} {
+		if n := counters[counter]; n > 0 {
+			if res[kind] == nil {
+				res[kind] = make(map[string]int64)
+			}
+			res[kind]["store"] = n
+		}
+	}
+}
+
+// resourceStats adds the total of each kind of resource to stats
+func resourceStats(stats Stats, res map[string]map[string]int64) {
+	for _, kind := range []string{resGoroutine, resTicker, resFile} {
+		var total int64
+		for _, n := range res[kind] {
+			total += n
+		}
+		stats[kind] = total
+	}
+}
+
+// checkLeaks logs whatever the cache or its released store s still hold
+// leakGrace after Close, unless the cache was reopened in the meantime
+func (c *Cache) checkLeaks(s store.Store) {
+	time.Sleep(leakGrace)
+	if atomic.LoadInt32(&c.closed) == 0 {
+		return
+	}
+	res := c.res.snapshot()
+	if r, ok := s.(store.Reporter); ok {
+		addStoreResources(res, r.Counters())
+	}
+	if len(res) == 0 {
+		return
+	}
+	var held []interface{}
+	kinds := make([]string, 0, len(res))
+	for kind := range res {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+	for _, kind := range kinds {
+		held = append(held, kind, res[kind])
+	}
+	c.logger.Warn("Resources still held after Close", held...)
+}
@@ -0,0 +1,66 @@
+package LCache_go
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// RetryPolicy retries failed loader calls, see CacheOptions.LoaderRetry. The
+// whole sequence of attempts stays within LoaderTimeout.
+
+type RetryPolicy struct {
+	MaxAttempts int                  // Attempts including the first one, 1 or less means no retries
+	Backoff     time.Duration        // Wait before the first retry, doubled for each further one; defaults to 10ms
+	MaxBackoff  time.Duration        // Upper bound for a single wait, 0 means no bound
+	Retryable   func(err error) bool // Decides whether err is worth retrying, nil retries everything but ErrKeyNotFound
+}
+
+func (p RetryPolicy) validate() error {
+	if p.MaxAttempts < 0 || p.Backoff < 0 || p.MaxBackoff < 0 {
+		return fmt.Errorf("lcache: retry policy must not be negative")
+	}
+	return nil
+}
+
+func (p RetryPolicy) retryable(err error) bool {
+	if errors.Is(err, ErrKeyNotFound) {
+		return false
+	}
+	if p.Retryable != nil {
+		return p.Retryable(err)
+	}
+	return true
+}
+
+// fetchWithRetry runs fetch under the LoaderRetry policy, if any
+func (c *Cache) fetchWithRetry(ctx context.Context, key string) (ByteView, time.Duration, error) {
+	p := c.opts.LoaderRetry
+	if p == nil || p.MaxAttempts <= 1 {
+		return c.fetch(ctx, key)
+	}
+	backoff := p.Backoff
+	if backoff == 0 {
+		backoff = 10 * time.Millisecond
+	}
+	for attempt := 1; ; attempt++ {
+		val, ttl, err := c.fetch(ctx, key)
+		if err == nil || attempt == p.MaxAttempts || !p.retryable(err) {
+			return val, ttl, err
+		}
+		if p.MaxBackoff > 0 && backoff > p.MaxBackoff {
+			backoff = p.MaxBackoff
+		}
+		t := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return val, ttl, err
+		case <-t.C:
+		}
+		atomic.AddInt64(&c.loadRetries, 1)
+		backoff *= 2
+	}
+}
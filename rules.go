@@ -0,0 +1,82 @@
+package LCache_go
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// Rule gives a family of keys its own settings within one cache, see
+// CacheOptions.Rules. The first rule matching a key applies.
+
+type Rule struct {
+	Prefix        string        // Matches keys starting with Prefix
+	Pattern       string        // Or a regular expression matched against the key, exclusive with Prefix
+	TTL           time.Duration // Used for matching keys stored without a ttl, instead of DefaultTTL
+	MaxTTL        time.Duration // Caps any ttl of matching keys, 0 means no cap
+	MaxEntryBytes int64         // Largest value accepted for matching keys, 0 keeps MaxEntryBytes
+}
+
+type compiledRule struct {
+	Rule
+	re *regexp.Regexp
+}
+
+func compileRules(rules []Rule) ([]compiledRule, error) {
+	compiled := make([]compiledRule, 0, len(rules))
+	for i, r := range rules {
+		if (r.Prefix == "") == (r.Pattern == "") {
+			return nil, fmt.Errorf("lcache: rule %d needs exactly one of Prefix and Pattern", i)
+		}
+		if r.TTL < 0 || r.MaxTTL < 0 || r.MaxEntryBytes < 0 {
+			return nil, errors.New("lcache: rule settings must not be negative")
+		}
+		cr := compiledRule{Rule: r}
+		if r.Pattern != "" {
+			re, err := regexp.Compile(r.Pattern)
+			if err != nil {
+				return nil, fmt.Errorf("lcache: rule %d: %w", i, err)
+			}
+			cr.re = re
+		}
+		compiled = append(compiled, cr)
+	}
+	return compiled, nil
+}
+
+func (r *compiledRule) matches(key string) bool {
+	if r.re != nil {
+		return r.re.MatchString(key)
+	}
+	return strings.HasPrefix(key, r.Prefix)
+}
+
+// ruleFor returns the first rule matching key, nil if none does
+func (c *Cache) ruleFor(key string) *Rule {
+	for i := range c.rules {
+		if c.rules[i].matches(key) {
+			return &c.rules[i].Rule
+		}
+	}
+	return nil
+}
+
+// ttlFor resolves the ttl a write of key is stored with: an explicit ttl,
+// else the rule's TTL, else DefaultTTL, capped by the rule's MaxTTL
+func (c *Cache) ttlFor(key string, ttl time.Duration) time.Duration {
+	r := c.ruleFor(key)
+	if ttl == 0 {
+		if r != nil && r.TTL > 0 {
+			ttl = r.TTL
+		} else {
+			ttl = time.Duration(atomic.LoadInt64(&c.defaultTTL))
+		}
+	}
+	if r != nil && r.MaxTTL > 0 && (ttl == 0 || ttl > r.MaxTTL) {
+		ttl = r.MaxTTL
+	}
+	return ttl
+}
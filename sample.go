@@ -0,0 +1,75 @@
+package LCache_go
+
+import (
+	"container/heap"
+	"lcache/store"
+	"math"
+	"math/rand"
+	"time"
+)
+
+// Sample returns the metadata of up to n entries picked uniformly at random,
+// for estimating TTL distribution, value sizes and key composition of a large
+// cache. It walks the store once under the read lock without copying the key
+// set; keys are as stored, like Scan.
+func (c *Cache) Sample(n int) []EntryInfo {
+	return c.sample(n, func(EntryInfo) float64 { return 1 })
+}
+
+// SampleBySize is Sample with each entry's chance of being picked proportional
+// to its size, so the sample describes where the bytes go rather than where the
+// keys are
+func (c *Cache) SampleBySize(n int) []EntryInfo {
+	return c.sample(n, func(e EntryInfo) float64 { return float64(e.Size) })
+}
+
+// sample is weighted reservoir sampling (Efraimidis-Spirakis): every entry gets
+// the priority u^(1/weight) and the n highest priorities are kept
+func (c *Cache) sample(n int, weight func(EntryInfo) float64) []EntryInfo {
+	if n <= 0 {
+		return nil
+	}
+	rng := rand.New(rand.NewSource(time.Now().UnixNano()))
+	res := make(sampleHeap, 0, n)
+	c.rangeEntries(func(key string, value store.Value, expiresAt time.Time) bool {
+		info := newEntryInfo(key, value, expiresAt)
+		w := weight(info)
+		if w <= 0 {
+			return true
+		}
+		p := math.Pow(rng.Float64(), 1/w)
+		if len(res) < n {
+			heap.Push(&res, sampleItem{priority: p, info: info})
+		} else if p > res[0].priority {
+			res[0] = sampleItem{priority: p, info: info}
+			heap.Fix(&res, 0)
+		}
+		return true
+	})
+
+	out := make([]EntryInfo, len(res))
+	for i, item := range res {
+		out[i] = item.info
+	}
+	return out
+}
+
+type sampleItem struct {
+	priority float64
+	info     EntryInfo
+}
+
+// sampleHeap is a min-heap on priority
+
+type sampleHeap []sampleItem
+
+func (h sampleHeap) Len() int            { return len(h) }
+func (h sampleHeap) Less(i, j int) bool  { return h[i].priority < h[j].priority }
+func (h sampleHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *sampleHeap) Push(x interface{}) { *h = append(*h, x.(sampleItem)) }
+func (h *sampleHeap) Pop() interface{} {
+	old := *h
+	item := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return item
+}
@@ -0,0 +1,76 @@
+package LCache_go
+
+import (
+	"container/heap"
+	"hash/fnv"
+	"lcache/store"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Scan returns up to count entries whose keys start with prefix, together with
+// the cursor for the next page. Start with cursor 0; a returned cursor of 0 means
+// the scan is complete. Entries are walked in key-hash order, so keys that live
+// for the whole scan are returned exactly once even while the cache is written to,
+// and a page costs O(n log count) without copying the key set.
+func (c *Cache) Scan(cursor uint64, prefix string, count int) ([]EntryInfo, uint64) {
+	if count <= 0 {
+		count = 10
+	}
+
+	page := make(scanPage, 0, count+1)
+	c.rangeEntries(func(key string, value store.Value, expiresAt time.Time) bool {
+		if !strings.HasPrefix(key, prefix) {
+			return true
+		}
+		h := scanHash(key)
+		if h <= cursor {
+			return true
+		}
+		if len(page) == count && h >= page[0].hash {
+			return true
+		}
+		heap.Push(&page, scanItem{hash: h, info: newEntryInfo(key, value, expiresAt)})
+		if len(page) > count {
+			heap.Pop(&page)
+		}
+		return true
+	})
+
+	sort.Slice(page, func(i, j int) bool { return page[i].hash < page[j].hash })
+	entries := make([]EntryInfo, len(page))
+	for i, item := range page {
+		entries[i] = item.info
+	}
+	if len(page) < count {
+		return entries, 0
+	}
+	return entries, page[len(page)-1].hash
+}
+
+func scanHash(key string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(key))
+	return h.Sum64()
+}
+
+type scanItem struct {
+	hash uint64
+	info EntryInfo
+}
+
+// scanPage is a max-heap on hash holding the smallest hashes seen so far
+
+type scanPage []scanItem
+
+func (p scanPage) Len() int            { return len(p) }
+func (p scanPage) Less(i, j int) bool  { return p[i].hash > p[j].hash }
+func (p scanPage) Swap(i, j int)       { p[i], p[j] = p[j], p[i] }
+func (p *scanPage) Push(x interface{}) { *p = append(*p, x.(scanItem)) }
+func (p *scanPage) Pop() interface{} {
+	old := *p
+	item := old[len(old)-1]
+	*p = old[:len(old)-1]
+	return item
+}
@@ -0,0 +1,282 @@
+package server
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	lcache "lcache"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MemcachedServer speaks the memcached text protocol: get, gets, set, cas,
+// delete, touch, flush_all, stats, version and quit. CAS tokens are entry versions. Item flags are accepted but not
+// stored, and always read back as 0.
+
+type MemcachedServer struct {
+	*connServer
+	cache *lcache.Cache
+}
+
+func NewMemcached(c *lcache.Cache) *MemcachedServer {
+	s := &MemcachedServer{cache: c}
+	s.connServer = newConnServer(s.handle)
+	return s
+}
+
+func (s *MemcachedServer) ListenAndServe(addr string) error {
+	return s.listenAndServe(addr)
+}
+
+func (s *MemcachedServer) Serve(l net.Listener) error {
+	return s.serve(l)
+}
+
+// memcachedMaxItem is the largest value set and cas accept, memcached's default
+// item size limit; the cache's own MaxEntryBytes still applies below it
+const memcachedMaxItem = 1024 * 1024
+
+// memcachedMaxLine bounds a command line, longer ones close the connection
+const memcachedMaxLine = 2048
+
+// memcached treats expiration times above 30 days as absolute unix timestamps
+const memcachedRelativeLimit = 60 * 60 * 24 * 30
+
+func (s *MemcachedServer) handle(conn net.Conn) {
+	r := bufio.NewReaderSize(conn, memcachedMaxLine)
+	w := bufio.NewWriter(conn)
+	for {
+		line, err := r.ReadSlice('\n')
+		if err == bufio.ErrBufferFull {
+			w.WriteString("CLIENT_ERROR line too long\r\n")
+			w.Flush()
+			return
+		}
+		if err != nil {
+			return
+		}
+		fields := strings.Fields(string(line))
+		if len(fields) == 0 {
+			w.WriteString("ERROR\r\n")
+		} else if quit := s.dispatch(fields, r, w); quit {
+			w.Flush()
+			return
+		}
+		if w.Flush() != nil || s.isClosing() {
+			return
+		}
+	}
+}
+
+func (s *MemcachedServer) dispatch(fields []string, r *bufio.Reader, w *bufio.Writer) bool {
+	switch strings.ToLower(fields[0]) {
+	case "get":
+		s.get(fields[1:], w, false)
+	case "gets":
+		s.get(fields[1:], w, true)
+	case "set":
+		s.set(fields[1:], r, w, false)
+	case "cas":
+		s.set(fields[1:], r, w, true)
+	case "delete":
+		s.delete(fields[1:], w)
+	case "touch":
+		s.touch(fields[1:], w)
+	case "flush_all":
+		s.cache.Clear()
+		reply(w, fields, "OK")
+	case "stats":
+		s.stats(w)
+	case "version":
+		w.WriteString("VERSION lcache\r\n")
+	case "quit":
+		return true
+	default:
+		w.WriteString("ERROR\r\n")
+	}
+	return false
+}
+
+func (s *MemcachedServer) get(keys []string, w *bufio.Writer, withCAS bool) {
+	if len(keys) == 0 {
+		w.WriteString("ERROR\r\n")
+		return
+	}
+	for _, key := range keys {
+		if !withCAS {
+			if bv, ok := s.cache.Get(key); ok {
+				fmt.Fprintf(w, "VALUE %s 0 %d\r\n", key, bv.Len())
+				w.Write(bv.ByteSlice())
+				w.WriteString("\r\n")
+			}
+			continue
+		}
+		if bv, version, err := s.cache.GetWithVersion(key); err == nil {
+			fmt.Fprintf(w, "VALUE %s 0 %d %d\r\n", key, bv.Len(), version)
+			w.Write(bv.ByteSlice())
+			w.WriteString("\r\n")
+		}
+	}
+	w.WriteString("END\r\n")
+}
+
+// set <key> <flags> <exptime> <bytes> [noreply]\r\n<data>\r\n
+// cas <key> <flags> <exptime> <bytes> <cas unique> [noreply]\r\n<data>\r\n
+func (s *MemcachedServer) set(args []string, r *bufio.Reader, w *bufio.Writer, cas bool) {
+	if len(args) < 4 || (cas && len(args) < 5) {
+		w.WriteString("CLIENT_ERROR bad command line format\r\n")
+		return
+	}
+	exptime, err1 := strconv.ParseInt(args[2], 10, 64)
+	size, err2 := strconv.Atoi(args[3])
+	var version uint64
+	var err3 error
+	if cas {
+		version, err3 = strconv.ParseUint(args[4], 10, 64)
+	}
+	if err1 != nil || err2 != nil || err3 != nil || size < 0 {
+		w.WriteString("CLIENT_ERROR bad command line format\r\n")
+		return
+	}
+	if size > memcachedMaxItem {
+		// answer right away, then swallow the payload like memcached does
+		// without holding it
+		w.WriteString("SERVER_ERROR object too large for cache\r\n")
+		if w.Flush() == nil {
+			if _, err := io.CopyN(io.Discard, r, int64(size)); err == nil {
+				io.CopyN(io.Discard, r, 2)
+			}
+		}
+		return
+	}
+	data := make([]byte, size+2)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return
+	}
+	if string(data[size:]) != "\r\n" {
+		w.WriteString("CLIENT_ERROR bad data chunk\r\n")
+		return
+	}
+
+	ttl, expired := memcachedTTL(exptime)
+	if cas {
+		s.cas(args, data[:size], ttl, expired, version, w)
+		return
+	}
+	if expired {
+		s.cache.Remove(args[0])
+		reply(w, args, "STORED")
+		return
+	}
+	var err error
+	if ttl > 0 {
+		err = s.cache.SetWithTTL(args[0], lcache.NewByteView(data[:size]), ttl)
+	} else {
+		err = s.cache.Set(args[0], lcache.NewByteView(data[:size]))
+	}
+	switch {
+	case err == nil:
+		reply(w, args, "STORED")
+	case errors.Is(err, lcache.ErrValueTooLarge):
+		reply(w, args, "SERVER_ERROR object too large for cache")
+	default:
+		reply(w, args, "SERVER_ERROR "+err.Error())
+	}
+}
+
+func (s *MemcachedServer) cas(args []string, data []byte, ttl time.Duration, expired bool, version uint64, w *bufio.Writer) {
+	var err error
+	switch {
+	case expired:
+		err = s.cache.DeleteIfVersion(args[0], version)
+	case ttl > 0:
+		_, err = s.cache.SetIfVersionWithTTL(args[0], lcache.NewByteView(data), ttl, version)
+	default:
+		_, err = s.cache.SetIfVersion(args[0], lcache.NewByteView(data), version)
+	}
+	switch {
+	case err == nil:
+		reply(w, args, "STORED")
+	case errors.Is(err, lcache.ErrVersionMismatch):
+		reply(w, args, "EXISTS")
+	case errors.Is(err, lcache.ErrKeyNotFound):
+		reply(w, args, "NOT_FOUND")
+	default:
+		reply(w, args, "SERVER_ERROR "+err.Error())
+	}
+}
+
+func (s *MemcachedServer) delete(args []string, w *bufio.Writer) {
+	if len(args) < 1 {
+		w.WriteString("ERROR\r\n")
+		return
+	}
+	if s.cache.Delete(args[0]) {
+		reply(w, args, "DELETED")
+	} else {
+		reply(w, args, "NOT_FOUND")
+	}
+}
+
+// touch <key> <exptime> [noreply]
+func (s *MemcachedServer) touch(args []string, w *bufio.Writer) {
+	if len(args) < 2 {
+		w.WriteString("ERROR\r\n")
+		return
+	}
+	exptime, err := strconv.ParseInt(args[1], 10, 64)
+	if err != nil {
+		w.WriteString("CLIENT_ERROR bad command line format\r\n")
+		return
+	}
+	ttl, expired := memcachedTTL(exptime)
+	if expired {
+		err = s.cache.Remove(args[0])
+	} else {
+		err = s.cache.Expire(args[0], ttl)
+	}
+	if err != nil {
+		reply(w, args, "NOT_FOUND")
+	} else {
+		reply(w, args, "TOUCHED")
+	}
+}
+
+func (s *MemcachedServer) stats(w *bufio.Writer) {
+	stats := s.cache.Stats()
+	fmt.Fprintf(w, "STAT curr_items %v\r\n", stats["size"])
+	fmt.Fprintf(w, "STAT bytes %v\r\n", stats["used_bytes"])
+	fmt.Fprintf(w, "STAT limit_maxbytes %v\r\n", stats["max_bytes"])
+	fmt.Fprintf(w, "STAT get_hits %v\r\n", stats["hits"])
+	fmt.Fprintf(w, "STAT get_misses %v\r\n", stats["misses"])
+	if v, ok := stats["evictions"]; ok {
+		fmt.Fprintf(w, "STAT evictions %v\r\n", v)
+	}
+	w.WriteString("END\r\n")
+}
+
+// memcachedTTL converts an exptime into a ttl; expired reports a time in the past
+func memcachedTTL(exptime int64) (ttl time.Duration, expired bool) {
+	switch {
+	case exptime < 0:
+		return 0, true
+	case exptime == 0:
+		return 0, false
+	case exptime > memcachedRelativeLimit:
+		ttl = time.Until(time.Unix(exptime, 0))
+		return ttl, ttl <= 0
+	default:
+		return time.Duration(exptime) * time.Second, false
+	}
+}
+
+// reply writes msg unless the command ended with noreply
+func reply(w *bufio.Writer, args []string, msg string) {
+	if len(args) > 0 && args[len(args)-1] == "noreply" {
+		return
+	}
+	w.WriteString(msg + "\r\n")
+}
@@ -0,0 +1,292 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	lcache "lcache"
+	"strings"
+	"sync"
+)
+
+// Keyspace notifications follow Redis: with notify-keyspace-events set (CONFIG
+// SET or RESPServer.SetKeyspaceEvents), a change to key publishes the event
+// name on __keyspace@0__:<key> (class K) and the key on __keyevent@0__:<event>
+// (class E). Events are set ($), del and expire (g), expired (x) and evicted (e);
+// A is an alias for all of them. Other Redis classes are accepted and ignored.
+const (
+	notifyKeyspace = 1 << iota
+	notifyKeyevent
+	notifyGeneric
+	notifyString
+	notifyExpired
+	notifyEvicted
+
+	notifyAll = notifyGeneric | notifyString | notifyExpired | notifyEvicted
+)
+
+// pushBuffer is how many undelivered messages a subscriber may have before new
+// ones are dropped
+const pushBuffer = 1024
+
+func parseNotifyFlags(s string) (int, error) {
+	flags := 0
+	for _, c := range s {
+		switch c {
+		case 'K':
+			flags |= notifyKeyspace
+		case 'E':
+			flags |= notifyKeyevent
+		case 'g':
+			flags |= notifyGeneric
+		case '$':
+			flags |= notifyString
+		case 'x':
+			flags |= notifyExpired
+		case 'e':
+			flags |= notifyEvicted
+		case 'A':
+			flags |= notifyAll
+		case 'l', 's', 'h', 'z', 't', 'm', 'd', 'n':
+		default:
+			return 0, fmt.Errorf("invalid notify-keyspace-events class %q", c)
+		}
+	}
+	return flags, nil
+}
+
+func formatNotifyFlags(flags int) string {
+	var b strings.Builder
+	if flags&notifyAll == notifyAll {
+		b.WriteByte('A')
+	} else {
+		for _, f := range []struct {
+			flag int
+			c    byte
+		}{{notifyGeneric, 'g'}, {notifyString, '$'}, {notifyExpired, 'x'}, {notifyEvicted, 'e'}} {
+			if flags&f.flag != 0 {
+				b.WriteByte(f.c)
+			}
+		}
+	}
+	if flags&notifyKeyspace != 0 {
+		b.WriteByte('K')
+	}
+	if flags&notifyKeyevent != 0 {
+		b.WriteByte('E')
+	}
+	return b.String()
+}
+
+// pubsub tracks subscribed connections and feeds them cache events while
+// anyone is subscribed
+
+type pubsub struct {
+	mu    sync.Mutex
+	flags int
+	conns map[*respConn]struct{}
+	stop  context.CancelFunc // ends the event feed, nil while nobody is subscribed
+}
+
+type pushMessage struct {
+	pattern string // empty for a plain channel subscription
+	channel string
+	payload string
+}
+
+// SetKeyspaceEvents sets notify-keyspace-events, e.g. "Ex" for expirations on
+// __keyevent@0__:expired, or "" to turn notifications off
+func (s *RESPServer) SetKeyspaceEvents(flags string) error {
+	f, err := parseNotifyFlags(flags)
+	if err != nil {
+		return err
+	}
+	s.ps.mu.Lock()
+	s.ps.flags = f
+	s.ps.mu.Unlock()
+	return nil
+}
+
+func (s *RESPServer) keyspaceEvents() string {
+	s.ps.mu.Lock()
+	defer s.ps.mu.Unlock()
+	return formatNotifyFlags(s.ps.flags)
+}
+
+// subscribe handles SUBSCRIBE and PSUBSCRIBE
+func (s *RESPServer) subscribe(rc *respConn, cmd string, names []string) {
+	pattern := cmd == "PSUBSCRIBE"
+	if len(names) == 0 {
+		rc.wrongArgs(cmd)
+		return
+	}
+	rc.subMu.Lock()
+	if rc.channels == nil {
+		rc.channels = make(map[string]struct{})
+		rc.patterns = make(map[string]struct{})
+		rc.pushes = make(chan pushMessage, pushBuffer)
+		go s.pusher(rc, rc.pushes)
+	}
+	set := rc.channels
+	if pattern {
+		set = rc.patterns
+	}
+	for _, name := range names {
+		set[name] = struct{}{}
+		rc.push(3)
+		rc.bulk(strings.ToLower(cmd))
+		rc.bulk(name)
+		rc.integer(int64(len(rc.channels) + len(rc.patterns)))
+	}
+	rc.subMu.Unlock()
+
+	s.ps.mu.Lock()
+	defer s.ps.mu.Unlock()
+	if s.ps.conns == nil {
+		s.ps.conns = make(map[*respConn]struct{})
+	}
+	s.ps.conns[rc] = struct{}{}
+	if s.ps.stop == nil {
+		ctx, cancel := context.WithCancel(context.Background())
+		s.ps.stop = cancel
+		go s.feed(s.cache.Subscribe(ctx, pushBuffer, lcache.EventSet, lcache.EventUpdate,
+			lcache.EventDelete, lcache.EventExpire, lcache.EventEvict))
+	}
+}
+
+// unsubscribe handles UNSUBSCRIBE and PUNSUBSCRIBE, no names meaning all of them
+func (s *RESPServer) unsubscribe(rc *respConn, cmd string, names []string) {
+	rc.subMu.Lock()
+	set := rc.channels
+	if cmd == "PUNSUBSCRIBE" {
+		set = rc.patterns
+	}
+	if len(names) == 0 {
+		for name := range set {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		rc.push(3)
+		rc.bulk(strings.ToLower(cmd))
+		rc.null()
+		rc.integer(int64(len(rc.channels) + len(rc.patterns)))
+	}
+	for _, name := range names {
+		delete(set, name)
+		rc.push(3)
+		rc.bulk(strings.ToLower(cmd))
+		rc.bulk(name)
+		rc.integer(int64(len(rc.channels) + len(rc.patterns)))
+	}
+	left := len(rc.channels) + len(rc.patterns)
+	rc.subMu.Unlock()
+
+	if left == 0 {
+		// back out of subscribed mode, a later SUBSCRIBE starts over
+		s.dropSubscriber(rc)
+	}
+}
+
+// subscribed reports whether rc is in subscribed mode
+func (rc *respConn) subscribed() bool {
+	rc.subMu.Lock()
+	defer rc.subMu.Unlock()
+	return len(rc.channels)+len(rc.patterns) > 0
+}
+
+// dropSubscriber forgets rc once its connection ends or it unsubscribed from
+// everything, stopping the feed when it was the last subscriber
+func (s *RESPServer) dropSubscriber(rc *respConn) {
+	s.ps.mu.Lock()
+	defer s.ps.mu.Unlock()
+	if _, ok := s.ps.conns[rc]; !ok {
+		return
+	}
+	delete(s.ps.conns, rc)
+	rc.subMu.Lock()
+	close(rc.pushes)
+	rc.channels, rc.patterns, rc.pushes = nil, nil, nil
+	rc.subMu.Unlock()
+	if len(s.ps.conns) == 0 && s.ps.stop != nil {
+		s.ps.stop()
+		s.ps.stop = nil
+	}
+}
+
+// feed turns cache events into notifications until the subscription ends
+func (s *RESPServer) feed(events <-chan lcache.KeyEvent) {
+	for ev := range events {
+		switch ev.Type {
+		case lcache.EventSet, lcache.EventUpdate:
+			s.notify("set", ev.Key, notifyString)
+		case lcache.EventDelete:
+			s.notify("del", ev.Key, notifyGeneric)
+		case lcache.EventExpire:
+			s.notify("expired", ev.Key, notifyExpired)
+		case lcache.EventEvict:
+			s.notify("evicted", ev.Key, notifyEvicted)
+		}
+	}
+}
+
+// notify publishes event for key if its class is enabled
+func (s *RESPServer) notify(event, key string, class int) {
+	s.ps.mu.Lock()
+	defer s.ps.mu.Unlock()
+	if s.ps.flags&class == 0 || len(s.ps.conns) == 0 {
+		return
+	}
+	if s.ps.flags&notifyKeyspace != 0 {
+		s.publish("__keyspace@0__:"+key, event)
+	}
+	if s.ps.flags&notifyKeyevent != 0 {
+		s.publish("__keyevent@0__:"+event, key)
+	}
+}
+
+// publish queues payload for every subscriber of channel, need to hold s.ps.mu
+func (s *RESPServer) publish(channel, payload string) {
+	for rc := range s.ps.conns {
+		rc.subMu.Lock()
+		var msgs []pushMessage
+		if _, ok := rc.channels[channel]; ok {
+			msgs = append(msgs, pushMessage{channel: channel, payload: payload})
+		}
+		for p := range rc.patterns {
+			if lcache.MatchPattern(p, channel) {
+				msgs = append(msgs, pushMessage{pattern: p, channel: channel, payload: payload})
+			}
+		}
+		rc.subMu.Unlock()
+		for _, m := range msgs {
+			select {
+			case rc.pushes <- m:
+			default:
+				// a subscriber that can't keep up loses messages, like a Redis
+				// client hitting its output buffer limit
+			}
+		}
+	}
+}
+
+// pusher writes queued messages to rc until dropSubscriber closes the queue
+func (s *RESPServer) pusher(rc *respConn, pushes <-chan pushMessage) {
+	for m := range pushes {
+		rc.mu.Lock()
+		if m.pattern != "" {
+			rc.push(4)
+			rc.bulk("pmessage")
+			rc.bulk(m.pattern)
+		} else {
+			rc.push(3)
+			rc.bulk("message")
+		}
+		rc.bulk(m.channel)
+		rc.bulk(m.payload)
+		err := rc.w.Flush()
+		rc.mu.Unlock()
+		if err != nil {
+			return
+		}
+	}
+}
@@ -0,0 +1,624 @@
+package server
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	lcache "lcache"
+	"lcache/auth"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RESPServer speaks the Redis protocol (RESP2, or RESP3 after HELLO 3) with
+// GET, SET, DEL, EXISTS, EXPIRE, PEXPIRE, TTL, PTTL, SCAN, DBSIZE and INFO,
+// plus SUBSCRIBE, PSUBSCRIBE, their UNSUBSCRIBEs and CONFIG GET/SET
+// notify-keyspace-events for keyspace notifications (see pubsub.go).
+// There is a single database, SELECT only accepts 0.
+
+type RESPServer struct {
+	*connServer
+	cache *lcache.Cache
+	auth  *auth.Authenticator
+	ps    pubsub
+}
+
+func NewRESP(c *lcache.Cache) *RESPServer {
+	s := &RESPServer{cache: c}
+	s.connServer = newConnServer(s.handle)
+	return s
+}
+
+// WithAuth requires clients to AUTH (or HELLO with AUTH) before running commands;
+// read-only credentials may not run SET, DEL, EXPIRE or PEXPIRE. AUTH with a
+// single argument checks a token, with two a user and password.
+func (s *RESPServer) WithAuth(a *auth.Authenticator) *RESPServer {
+	s.auth = a
+	return s
+}
+
+// commandRoles lists the role each data command needs, others need none
+var commandRoles = map[string]auth.Role{
+	"GET":        auth.RoleRead,
+	"EXISTS":     auth.RoleRead,
+	"TTL":        auth.RoleRead,
+	"PTTL":       auth.RoleRead,
+	"SCAN":       auth.RoleRead,
+	"DBSIZE":     auth.RoleRead,
+	"INFO":       auth.RoleRead,
+	"CONFIG":     auth.RoleRead,
+	"SUBSCRIBE":  auth.RoleRead,
+	"PSUBSCRIBE": auth.RoleRead,
+	"SET":        auth.RoleWrite,
+	"DEL":        auth.RoleWrite,
+	"EXPIRE":     auth.RoleWrite,
+	"PEXPIRE":    auth.RoleWrite,
+}
+
+func (s *RESPServer) ListenAndServe(addr string) error {
+	return s.listenAndServe(addr)
+}
+
+func (s *RESPServer) Serve(l net.Listener) error {
+	return s.serve(l)
+}
+
+var errProtocol = errors.New("protocol error")
+
+// limits on what a client may send readCommand, anything beyond them is a
+// protocol error that closes the connection. Like Redis, a connection that
+// still has to AUTH gets much tighter ones. Buffers grow as bytes arrive, a
+// declared length alone allocates nothing.
+const (
+	maxMultibulkLen       = 1024 * 1024
+	maxBulkLen            = 64 * 1024 * 1024
+	maxInlineLen          = 64 * 1024
+	maxUnauthMultibulkLen = 10
+	maxUnauthBulkLen      = 16 * 1024
+)
+
+type respConn struct {
+	r     *bufio.Reader
+	w     *bufio.Writer
+	mu    sync.Mutex // serializes writes to w between replies and pushed messages
+	proto int
+	role  auth.Role
+
+	subMu    sync.Mutex
+	channels map[string]struct{} // nil until the first subscription
+	patterns map[string]struct{}
+	pushes   chan pushMessage
+}
+
+func (s *RESPServer) handle(conn net.Conn) {
+	rc := &respConn{r: bufio.NewReader(conn), w: bufio.NewWriter(conn), proto: 2, role: auth.RoleWrite}
+	if s.auth != nil {
+		rc.role = auth.RoleNone
+	}
+	defer s.dropSubscriber(rc)
+	for {
+		args, err := rc.readCommand()
+		if err != nil {
+			if errors.Is(err, errProtocol) {
+				rc.error("ERR Protocol error" + strings.TrimPrefix(err.Error(), errProtocol.Error()))
+				rc.w.Flush()
+			}
+			return
+		}
+		if len(args) == 0 {
+			continue
+		}
+		rc.mu.Lock()
+		quit := s.dispatch(rc, args)
+		err = rc.w.Flush()
+		rc.mu.Unlock()
+		if err != nil || quit || s.isClosing() {
+			return
+		}
+	}
+}
+
+func (s *RESPServer) dispatch(rc *respConn, args []string) bool {
+	cmd := strings.ToUpper(args[0])
+	if need, ok := commandRoles[cmd]; ok && !rc.role.Allows(need) {
+		if rc.role == auth.RoleNone {
+			rc.error("NOAUTH Authentication required.")
+		} else {
+			rc.error(fmt.Sprintf("NOPERM this user has no permissions to run the '%s' command", strings.ToLower(cmd)))
+		}
+		return false
+	}
+	if rc.proto == 2 && rc.subscribed() {
+		switch cmd {
+		case "SUBSCRIBE", "PSUBSCRIBE", "UNSUBSCRIBE", "PUNSUBSCRIBE", "QUIT":
+		case "PING":
+			rc.array(2)
+			rc.bulk("pong")
+			if len(args) > 1 {
+				rc.bulk(args[1])
+			} else {
+				rc.bulk("")
+			}
+			return false
+		default:
+			rc.error(fmt.Sprintf("ERR Can't execute '%s': only (P)SUBSCRIBE / (P)UNSUBSCRIBE / PING / QUIT are allowed in this context", strings.ToLower(cmd)))
+			return false
+		}
+	}
+	switch cmd {
+	case "PING":
+		if len(args) > 1 {
+			rc.bulk(args[1])
+		} else {
+			rc.simple("PONG")
+		}
+	case "ECHO":
+		if !rc.arity(args, 2) {
+			break
+		}
+		rc.bulk(args[1])
+	case "AUTH":
+		if len(args) < 2 || len(args) > 3 {
+			rc.wrongArgs(cmd)
+			break
+		}
+		if s.authenticate(rc, args[1:]) {
+			rc.simple("OK")
+		}
+	case "HELLO":
+		s.hello(rc, args[1:])
+	case "SELECT":
+		if !rc.arity(args, 2) {
+			break
+		}
+		if args[1] != "0" {
+			rc.error("ERR DB index is out of range")
+			break
+		}
+		rc.simple("OK")
+	case "COMMAND":
+		rc.array(0)
+	case "GET":
+		if !rc.arity(args, 2) {
+			break
+		}
+		if bv, ok := s.cache.Get(args[1]); ok {
+			rc.bulk(bv.String())
+		} else {
+			rc.null()
+		}
+	case "SET":
+		s.set(rc, args[1:])
+	case "DEL":
+		if len(args) < 2 {
+			rc.wrongArgs(cmd)
+			break
+		}
+		n := 0
+		for _, key := range args[1:] {
+			if s.cache.Delete(key) {
+				n++
+			}
+		}
+		rc.integer(int64(n))
+	case "EXISTS":
+		if len(args) < 2 {
+			rc.wrongArgs(cmd)
+			break
+		}
+		n := 0
+		for _, key := range args[1:] {
+			if s.cache.Has(key) {
+				n++
+			}
+		}
+		rc.integer(int64(n))
+	case "EXPIRE", "PEXPIRE":
+		if !rc.arity(args, 3) {
+			break
+		}
+		unit := time.Second
+		if cmd == "PEXPIRE" {
+			unit = time.Millisecond
+		}
+		s.expire(rc, args[1], args[2], unit)
+	case "TTL", "PTTL":
+		if !rc.arity(args, 2) {
+			break
+		}
+		s.ttl(rc, args[1], cmd == "PTTL")
+	case "SCAN":
+		s.scan(rc, args[1:])
+	case "DBSIZE":
+		rc.integer(int64(s.cache.Len()))
+	case "INFO":
+		s.info(rc)
+	case "CONFIG":
+		s.config(rc, args[1:])
+	case "SUBSCRIBE", "PSUBSCRIBE":
+		s.subscribe(rc, cmd, args[1:])
+	case "UNSUBSCRIBE", "PUNSUBSCRIBE":
+		s.unsubscribe(rc, cmd, args[1:])
+	case "QUIT":
+		rc.simple("OK")
+		return true
+	default:
+		rc.error(fmt.Sprintf("ERR unknown command '%s'", args[0]))
+	}
+	return false
+}
+
+// authenticate checks AUTH [user] password, replying with an error on failure
+func (s *RESPServer) authenticate(rc *respConn, args []string) bool {
+	var role auth.Role
+	if len(args) == 1 {
+		role = s.auth.Token(args[0])
+	} else {
+		role = s.auth.User(args[0], args[1])
+	}
+	if role == auth.RoleNone {
+		rc.error("WRONGPASS invalid username-password pair or user is disabled.")
+		return false
+	}
+	rc.role = role
+	return true
+}
+
+// HELLO [protover [AUTH username password]]
+func (s *RESPServer) hello(rc *respConn, args []string) {
+	proto := rc.proto
+	if len(args) > 0 {
+		var err error
+		proto, err = strconv.Atoi(args[0])
+		if err != nil || proto < 2 || proto > 3 {
+			rc.error("NOPROTO unsupported protocol version")
+			return
+		}
+	}
+	for i := 1; i < len(args); i++ {
+		if strings.ToUpper(args[i]) != "AUTH" || i+2 >= len(args) {
+			rc.error("ERR syntax error in HELLO option")
+			return
+		}
+		if !s.authenticate(rc, args[i+1:i+3]) {
+			return
+		}
+		i += 2
+	}
+	if rc.role == auth.RoleNone {
+		rc.error("NOAUTH HELLO must be called with the client already authenticated, otherwise the HELLO AUTH <user> <pass> option can be used to authenticate the client and select the RESP protocol version at the same time")
+		return
+	}
+	rc.proto = proto
+	rc.mapHeader(4)
+	rc.bulk("server")
+	rc.bulk("lcache")
+	rc.bulk("proto")
+	rc.integer(int64(rc.proto))
+	rc.bulk("mode")
+	rc.bulk("standalone")
+	rc.bulk("role")
+	rc.bulk("master")
+}
+
+// SET key value [EX seconds | PX milliseconds]
+func (s *RESPServer) set(rc *respConn, args []string) {
+	if len(args) < 2 {
+		rc.wrongArgs("SET")
+		return
+	}
+	var ttl time.Duration
+	for i := 2; i < len(args); i++ {
+		opt := strings.ToUpper(args[i])
+		if (opt != "EX" && opt != "PX") || i+1 >= len(args) {
+			rc.error("ERR syntax error")
+			return
+		}
+		n, err := strconv.ParseInt(args[i+1], 10, 64)
+		if err != nil || n <= 0 {
+			rc.error("ERR invalid expire time in 'set' command")
+			return
+		}
+		if opt == "EX" {
+			ttl = time.Duration(n) * time.Second
+		} else {
+			ttl = time.Duration(n) * time.Millisecond
+		}
+		i++
+	}
+
+	value := lcache.NewByteView([]byte(args[1]))
+	var err error
+	if ttl > 0 {
+		err = s.cache.SetWithTTL(args[0], value, ttl)
+	} else {
+		err = s.cache.Set(args[0], value)
+	}
+	if err != nil {
+		rc.error("ERR " + err.Error())
+		return
+	}
+	rc.simple("OK")
+}
+
+func (s *RESPServer) expire(rc *respConn, key, arg string, unit time.Duration) {
+	n, err := strconv.ParseInt(arg, 10, 64)
+	if err != nil {
+		rc.error("ERR value is not an integer or out of range")
+		return
+	}
+	// like redis, a non-positive timeout deletes the key
+	if n <= 0 {
+		if s.cache.Delete(key) {
+			rc.integer(1)
+		} else {
+			rc.integer(0)
+		}
+		return
+	}
+	if err := s.cache.Expire(key, time.Duration(n)*unit); err != nil {
+		rc.integer(0)
+		return
+	}
+	s.notify("expire", key, notifyGeneric)
+	rc.integer(1)
+}
+
+// config handles CONFIG GET and CONFIG SET, notify-keyspace-events is the only
+// parameter; GET of anything else finds nothing
+func (s *RESPServer) config(rc *respConn, args []string) {
+	if len(args) == 0 {
+		rc.wrongArgs("config")
+		return
+	}
+	const param = "notify-keyspace-events"
+	switch strings.ToUpper(args[0]) {
+	case "GET":
+		if len(args) != 2 {
+			rc.wrongArgs("config|get")
+			return
+		}
+		if !lcache.MatchPattern(strings.ToLower(args[1]), param) {
+			rc.mapHeader(0)
+			return
+		}
+		rc.mapHeader(1)
+		rc.bulk(param)
+		rc.bulk(s.keyspaceEvents())
+	case "SET":
+		if len(args) != 3 {
+			rc.wrongArgs("config|set")
+			return
+		}
+		if !rc.role.Allows(auth.RoleWrite) {
+			rc.error("NOPERM this user has no permissions to run the 'config|set' command")
+			return
+		}
+		if strings.ToLower(args[1]) != param {
+			rc.error(fmt.Sprintf("ERR Unknown option or number of arguments for CONFIG SET - '%s'", args[1]))
+			return
+		}
+		if err := s.SetKeyspaceEvents(args[2]); err != nil {
+			rc.error("ERR CONFIG SET failed (possibly related to argument '" + param + "') - " + err.Error())
+			return
+		}
+		rc.simple("OK")
+	default:
+		rc.error(fmt.Sprintf("ERR unknown subcommand '%s'", args[0]))
+	}
+}
+
+// ttl replies -2 for a missing key and -1 for a key without expiration
+func (s *RESPServer) ttl(rc *respConn, key string, millis bool) {
+	info, _, ok := s.cache.Inspect(key)
+	switch {
+	case !ok:
+		rc.integer(-2)
+	case info.ExpiresAt.IsZero():
+		rc.integer(-1)
+	case millis:
+		rc.integer(info.TTL().Milliseconds())
+	default:
+		rc.integer(int64((info.TTL() + time.Second/2) / time.Second))
+	}
+}
+
+// SCAN cursor [MATCH pattern] [COUNT count]
+func (s *RESPServer) scan(rc *respConn, args []string) {
+	if len(args) < 1 {
+		rc.wrongArgs("SCAN")
+		return
+	}
+	cursor, err := strconv.ParseUint(args[0], 10, 64)
+	if err != nil {
+		rc.error("ERR invalid cursor")
+		return
+	}
+	pattern, count := "", 10
+	for i := 1; i < len(args); i += 2 {
+		if i+1 >= len(args) {
+			rc.error("ERR syntax error")
+			return
+		}
+		switch strings.ToUpper(args[i]) {
+		case "MATCH":
+			pattern = args[i+1]
+		case "COUNT":
+			count, err = strconv.Atoi(args[i+1])
+			if err != nil || count <= 0 {
+				rc.error("ERR syntax error")
+				return
+			}
+		default:
+			rc.error("ERR syntax error")
+			return
+		}
+	}
+
+	entries, next := s.cache.Scan(cursor, lcache.PatternPrefix(pattern), count)
+	keys := make([]string, 0, len(entries))
+	for _, e := range entries {
+		if pattern == "" || lcache.MatchPattern(pattern, e.Key) {
+			keys = append(keys, e.Key)
+		}
+	}
+	rc.array(2)
+	rc.bulk(strconv.FormatUint(next, 10))
+	rc.array(len(keys))
+	for _, key := range keys {
+		rc.bulk(key)
+	}
+}
+
+func (s *RESPServer) info(rc *respConn) {
+	stats := s.cache.Stats()
+	names := make([]string, 0, len(stats))
+	for name, v := range stats {
+		switch v.(type) {
+		case map[string]interface{}, []lcache.HistogramBucket:
+			continue
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	b.WriteString("# Server\r\nredis_mode:standalone\r\nlcache:1\r\n\r\n# Stats\r\n")
+	for _, name := range names {
+		fmt.Fprintf(&b, "%s:%v\r\n", name, stats[name])
+	}
+	fmt.Fprintf(&b, "\r\n# Keyspace\r\ndb0:keys=%d\r\n", s.cache.Len())
+	rc.bulk(b.String())
+}
+
+// readCommand reads a RESP array of bulk strings, or an inline command
+func (rc *respConn) readCommand() ([]string, error) {
+	line, err := rc.readLine()
+	if err != nil {
+		return nil, err
+	}
+	if len(line) == 0 || line[0] != '*' {
+		return strings.Fields(line), nil
+	}
+	maxArgs, maxBulk := maxMultibulkLen, maxBulkLen
+	if rc.role == auth.RoleNone {
+		maxArgs, maxBulk = maxUnauthMultibulkLen, maxUnauthBulkLen
+	}
+	n, err := strconv.Atoi(line[1:])
+	if err != nil || n < 0 || n > maxArgs {
+		return nil, fmt.Errorf("%w: invalid multibulk length", errProtocol)
+	}
+	var args []string
+	for len(args) < n {
+		line, err := rc.readLine()
+		if err != nil {
+			return nil, err
+		}
+		if len(line) == 0 || line[0] != '$' {
+			return nil, fmt.Errorf("%w: expected '$'", errProtocol)
+		}
+		// the cap also keeps size+2 from overflowing
+		size, err := strconv.Atoi(line[1:])
+		if err != nil || size < 0 || size > maxBulk {
+			return nil, fmt.Errorf("%w: invalid bulk length", errProtocol)
+		}
+		arg, err := rc.readBulk(size + 2)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, arg[:size])
+	}
+	return args, nil
+}
+
+// readBulk reads size bytes, copying them in chunks so the buffer only grows
+// as fast as the client actually sends
+func (rc *respConn) readBulk(size int) (string, error) {
+	var b strings.Builder
+	if _, err := io.CopyN(&b, rc.r, int64(size)); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// readLine reads up to '\n', an inline command may not exceed maxInlineLen
+func (rc *respConn) readLine() (string, error) {
+	var line []byte
+	for {
+		chunk, err := rc.r.ReadSlice('\n')
+		if len(line)+len(chunk) > maxInlineLen {
+			return "", fmt.Errorf("%w: too big inline request", errProtocol)
+		}
+		line = append(line, chunk...)
+		if err == bufio.ErrBufferFull {
+			continue
+		}
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimRight(string(line), "\r\n"), nil
+	}
+}
+
+func (rc *respConn) arity(args []string, n int) bool {
+	if len(args) != n {
+		rc.wrongArgs(args[0])
+		return false
+	}
+	return true
+}
+
+func (rc *respConn) wrongArgs(cmd string) {
+	rc.error(fmt.Sprintf("ERR wrong number of arguments for '%s' command", strings.ToLower(cmd)))
+}
+
+func (rc *respConn) simple(s string) {
+	rc.w.WriteString("+" + s + "\r\n")
+}
+
+func (rc *respConn) error(msg string) {
+	rc.w.WriteString("-" + strings.ReplaceAll(msg, "\r\n", " ") + "\r\n")
+}
+
+func (rc *respConn) integer(n int64) {
+	rc.w.WriteString(":" + strconv.FormatInt(n, 10) + "\r\n")
+}
+
+func (rc *respConn) bulk(s string) {
+	rc.w.WriteString("$" + strconv.Itoa(len(s)) + "\r\n" + s + "\r\n")
+}
+
+// push starts an out-of-band message of n elements, an array in RESP2
+func (rc *respConn) push(n int) {
+	if rc.proto == 3 {
+		rc.w.WriteString(">" + strconv.Itoa(n) + "\r\n")
+	} else {
+		rc.array(n)
+	}
+}
+
+func (rc *respConn) array(n int) {
+	rc.w.WriteString("*" + strconv.Itoa(n) + "\r\n")
+}
+
+func (rc *respConn) null() {
+	if rc.proto == 3 {
+		rc.w.WriteString("_\r\n")
+	} else {
+		rc.w.WriteString("$-1\r\n")
+	}
+}
+
+// mapHeader starts a map of n pairs, a flat array in RESP2
+func (rc *respConn) mapHeader(n int) {
+	if rc.proto == 3 {
+		rc.w.WriteString("%" + strconv.Itoa(n) + "\r\n")
+	} else {
+		rc.array(2 * n)
+	}
+}
@@ -0,0 +1,94 @@
+package LCache_go
+
+import (
+	"context"
+	"lcache/store"
+	"sync"
+	"time"
+)
+
+// Session protects the keys it touches from capacity eviction until Release,
+// so a batch computation's working set stays cached while it runs. Entries
+// still expire. Stores that can't pin keys (see store.Pinner) give no
+// protection but sessions keep working.
+
+type Session struct {
+	c        *Cache
+	mu       sync.Mutex
+	keys     map[string]struct{}
+	released bool
+}
+
+// Session starts a session, call Release when done with it
+func (c *Cache) Session() *Session {
+	return &Session{c: c, keys: make(map[string]struct{})}
+}
+
+// Pin protects key for the rest of the session, whether or not it is cached yet
+func (s *Session) Pin(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.released {
+		return
+	}
+	if _, ok := s.keys[key]; ok {
+		return
+	}
+	s.keys[key] = struct{}{}
+	s.c.withPinner(func(p store.Pinner) { p.Pin(s.c.storeKey(key)) })
+}
+
+func (s *Session) Get(key string) (ByteView, bool) {
+	s.Pin(key)
+	return s.c.Get(key)
+}
+
+func (s *Session) GetCtx(ctx context.Context, key string) (ByteView, error) {
+	s.Pin(key)
+	return s.c.GetCtx(ctx, key)
+}
+
+func (s *Session) Set(key string, value ByteView) error {
+	s.Pin(key)
+	return s.c.Set(key, value)
+}
+
+func (s *Session) SetWithTTL(key string, value ByteView, ttl time.Duration) error {
+	s.Pin(key)
+	return s.c.SetWithTTL(key, value, ttl)
+}
+
+// Len returns the number of keys pinned by the session
+func (s *Session) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.keys)
+}
+
+// Release unpins every key, letting the store evict them again. It is safe to
+// call more than once.
+func (s *Session) Release() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.released {
+		return
+	}
+	s.released = true
+	s.c.withPinner(func(p store.Pinner) {
+		for key := range s.keys {
+			p.Unpin(s.c.storeKey(key))
+		}
+	})
+	s.keys = nil
+}
+
+func (c *Cache) withPinner(fn func(p store.Pinner)) {
+	if !OpenedAndInitialized(c) {
+		return
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if p, ok := c.store.(store.Pinner); ok {
+		fn(p)
+	}
+}
@@ -0,0 +1,151 @@
+package LCache_go
+
+import (
+	"fmt"
+	"lcache/store"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ShadowOptions configure a shadow cache, see CacheOptions.Shadow. Zero fields
+// take the primary cache's settings.
+
+type ShadowOptions struct {
+	CacheType store.CacheType // Eviction policy to evaluate, defaults to the cache's CacheType
+	MaxBytes  int64           // Budget to evaluate, defaults to the cache's MaxBytes
+
+	// Store is used instead of CacheType and MaxBytes, e.g. a policy that isn't
+	// built in. It only ever holds placeholders sized like the real values.
+	Store store.Store
+}
+
+func (o ShadowOptions) validate() error {
+	if o.MaxBytes < 0 {
+		return fmt.Errorf("lcache: Shadow MaxBytes must not be negative, got %d", o.MaxBytes)
+	}
+	switch o.CacheType {
+	case store.LRU, "": // LRU2 isn't implemented yet
+	default:
+		return fmt.Errorf("lcache: unknown Shadow CacheType %q", o.CacheType)
+	}
+	return nil
+}
+
+// shadowValue stands in for a value in the shadow store, it has the size of
+// the real value without holding its bytes
+type shadowValue int
+
+func (v shadowValue) Len() int { return int(v) }
+
+// shadowCache mirrors the keys, sizes and expirations written to the cache and
+// replays its reads, counting the hits the shadow policy would have had. It
+// never serves a value.
+
+type shadowCache struct {
+	opts ShadowOptions
+
+	mu    sync.RWMutex
+	store store.Store // nil while the cache is closed
+
+	hits   int64
+	misses int64
+}
+
+func newShadowCache(opts ShadowOptions, primary CacheOptions) *shadowCache {
+	if opts.CacheType == "" {
+		opts.CacheType = primary.CacheType
+	}
+	if opts.MaxBytes == 0 {
+		opts.MaxBytes = primary.MaxBytes
+	}
+	return &shadowCache{opts: opts}
+}
+
+// open creates the shadow store alongside the cache's, cleanup following the
+// cache's CleanupTime
+func (s *shadowCache) open(cleanup time.Duration) error {
+	st := s.opts.Store
+	if st == nil {
+		var err error
+		st, err = store.NewStore(s.opts.CacheType, store.Options{MaxBytes: s.opts.MaxBytes, CleanupInterval: cleanup})
+		if err != nil {
+			return err
+		}
+	}
+	s.mu.Lock()
+	s.store = st
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *shadowCache) close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.store != nil {
+		s.store.Close()
+		s.store = nil
+	}
+}
+
+func (s *shadowCache) get(key string) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.store == nil {
+		return
+	}
+	if _, ok := s.store.Get(key); ok {
+		atomic.AddInt64(&s.hits, 1)
+	} else {
+		atomic.AddInt64(&s.misses, 1)
+	}
+}
+
+func (s *shadowCache) set(key string, size int, ttl time.Duration) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.store != nil {
+		// a value over the shadow's budget is a miss there, like any other eviction
+		s.store.SetWithExpiration(key, shadowValue(size), ttl)
+	}
+}
+
+func (s *shadowCache) delete(key string) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.store != nil {
+		s.store.Delete(key)
+	}
+}
+
+func (s *shadowCache) clear() {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.store != nil {
+		s.store.Clear()
+	}
+}
+
+func (s *shadowCache) reset() {
+	atomic.StoreInt64(&s.hits, 0)
+	atomic.StoreInt64(&s.misses, 0)
+}
+
+func (s *shadowCache) stats(stats Stats) {
+	hits, misses := atomic.LoadInt64(&s.hits), atomic.LoadInt64(&s.misses)
+	stats["shadow_cache_type"] = string(s.opts.CacheType)
+	stats["shadow_hits"] = hits
+	stats["shadow_misses"] = misses
+	if hits+misses > 0 {
+		stats["shadow_hit_rate"] = float64(hits) / float64(hits+misses)
+	} else {
+		stats["shadow_hit_rate"] = 0.0
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.store != nil {
+		stats["shadow_size"] = s.store.Len()
+		stats["shadow_used_bytes"] = s.store.UsedBytes()
+		stats["shadow_max_bytes"] = s.store.MaxBytes()
+	}
+}
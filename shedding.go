@@ -0,0 +1,58 @@
+package LCache_go
+
+import (
+	"fmt"
+	"math/rand"
+)
+
+// ShedPolicy decides what happens to new loads once MaxConcurrentLoads loads
+// are already running, see CacheOptions.LoadShedding
+
+type ShedPolicy int
+
+const (
+	// ShedNone never rejects loads
+	ShedNone ShedPolicy = iota
+	// ShedReject rejects every new load while MaxConcurrentLoads are running
+	ShedReject
+	// ShedProbabilistic starts rejecting at half of MaxConcurrentLoads, with a
+	// probability rising linearly to 1 at MaxConcurrentLoads, so the backend
+	// sees a gradual back-off instead of a cliff
+	ShedProbabilistic
+)
+
+func (p ShedPolicy) String() string {
+	switch p {
+	case ShedNone:
+		return "none"
+	case ShedReject:
+		return "reject"
+	case ShedProbabilistic:
+		return "probabilistic"
+	default:
+		return fmt.Sprintf("ShedPolicy(%d)", int(p))
+	}
+}
+
+// shouldShed reports whether a new load must be rejected given the number of
+// loads already running. Joining a load of the same key is never shed.
+func (c *Cache) shouldShed(running int64) bool {
+	limit := int64(c.opts.MaxConcurrentLoads)
+	if limit <= 0 || c.opts.LoadShedding == ShedNone {
+		return false
+	}
+	switch c.opts.LoadShedding {
+	case ShedReject:
+		return running >= limit
+	case ShedProbabilistic:
+		soft := limit / 2
+		if running < soft {
+			return false
+		}
+		if running >= limit {
+			return true
+		}
+		return rand.Float64() < float64(running-soft+1)/float64(limit-soft+1)
+	}
+	return false
+}
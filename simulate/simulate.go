@@ -0,0 +1,156 @@
+// Package simulate replays access traces against cache stores offline, to size
+// a cache and compare eviction policies before deploying them. A trace is
+// replayed once against a store per size, reads filling misses like a
+// cache-aside caller, and each run reports its hit rate and byte hit rate.
+// Expirations are not simulated.
+package simulate
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"lcache/store"
+	"strconv"
+)
+
+// Options configure Run
+
+type Options struct {
+	CacheType store.CacheType // Policy to replay against, defaults to store.LRU
+	Sizes     []int64         // MaxBytes of each simulated cache, at least one
+
+	// NewStore builds the store for a size instead of CacheType, e.g. a policy
+	// that isn't built in. Values are placeholders, only their Len matters.
+	NewStore func(maxBytes int64) (store.Store, error)
+}
+
+// Result is the outcome of replaying a trace at one size
+
+type Result struct {
+	MaxBytes  int64
+	Requests  int64 // OpGet accesses
+	Hits      int64
+	Bytes     int64 // bytes requested by OpGet accesses
+	HitBytes  int64 // of Bytes, those served from the cache
+	Evictions int64 // from the store's "evictions" counter if it is a store.Reporter
+}
+
+// HitRate is the fraction of reads served from the cache
+func (r Result) HitRate() float64 {
+	if r.Requests == 0 {
+		return 0
+	}
+	return float64(r.Hits) / float64(r.Requests)
+}
+
+// ByteHitRate is the fraction of bytes read served from the cache
+func (r Result) ByteHitRate() float64 {
+	if r.Bytes == 0 {
+		return 0
+	}
+	return float64(r.HitBytes) / float64(r.Bytes)
+}
+
+// value stands in for a cached value of a given size
+type value int
+
+func (v value) Len() int { return int(v) }
+
+// run is one simulated cache
+type run struct {
+	store  store.Store
+	result Result
+}
+
+// Run replays trace against a cache of every size in opts.Sizes in a single
+// pass and returns one Result per size, in the same order
+func Run(trace Reader, opts Options) ([]Result, error) {
+	if len(opts.Sizes) == 0 {
+		return nil, errors.New("simulate: no sizes")
+	}
+	runs := make([]*run, len(opts.Sizes))
+	defer func() {
+		for _, r := range runs {
+			if r != nil {
+				r.store.Close()
+			}
+		}
+	}()
+	for i, size := range opts.Sizes {
+		r := &run{result: Result{MaxBytes: size}}
+		s, err := opts.newStore(size)
+		if err != nil {
+			return nil, err
+		}
+		r.store = s
+		runs[i] = r
+	}
+
+	for {
+		a, err := trace.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range runs {
+			r.replay(a)
+		}
+	}
+
+	results := make([]Result, len(runs))
+	for i, r := range runs {
+		if rep, ok := r.store.(store.Reporter); ok {
+			r.result.Evictions = rep.Counters()["evictions"]
+		}
+		results[i] = r.result
+	}
+	return results, nil
+}
+
+func (o Options) newStore(size int64) (store.Store, error) {
+	if o.NewStore != nil {
+		return o.NewStore(size)
+	}
+	cacheType := o.CacheType
+	if cacheType == "" {
+		cacheType = store.LRU
+	}
+	return store.NewStore(cacheType, store.Options{MaxBytes: size})
+}
+
+func (r *run) replay(a Access) {
+	switch a.Op {
+	case OpGet:
+		r.result.Requests++
+		r.result.Bytes += int64(a.Size)
+		if v, ok := r.store.Get(a.Key); ok {
+			r.result.Hits++
+			r.result.HitBytes += int64(v.Len())
+			return
+		}
+		// a value larger than the cache fails to store, which is just a miss
+		r.store.Set(a.Key, value(a.Size))
+	case OpSet:
+		r.store.Set(a.Key, value(a.Size))
+	case OpDelete:
+		r.store.Delete(a.Key)
+	}
+}
+
+// WriteCSV writes results as a curve, one "max_bytes,requests,hits,hit_rate,
+// byte_hit_rate,evictions" row per size after a header
+func WriteCSV(w io.Writer, results []Result) error {
+	if _, err := fmt.Fprintln(w, "max_bytes,requests,hits,hit_rate,byte_hit_rate,evictions"); err != nil {
+		return err
+	}
+	for _, r := range results {
+		_, err := fmt.Fprintf(w, "%d,%d,%d,%s,%s,%d\n", r.MaxBytes, r.Requests, r.Hits,
+			strconv.FormatFloat(r.HitRate(), 'f', 6, 64), strconv.FormatFloat(r.ByteHitRate(), 'f', 6, 64), r.Evictions)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
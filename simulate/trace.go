@@ -0,0 +1,184 @@
+package simulate
+
+import (
+	"bufio"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Op is what an access does to its key
+type Op int
+
+const (
+	// OpGet reads the key and, on a miss, fills it like a cache-aside caller
+	OpGet Op = iota
+	// OpSet writes the key
+	OpSet
+	// OpDelete removes the key
+	OpDelete
+)
+
+// Access is one request of a trace
+type Access struct {
+	Key  string
+	Size int // value size in bytes
+	Op   Op
+}
+
+// Reader yields the accesses of a trace, Next returns io.EOF after the last one
+type Reader interface {
+	Next() (Access, error)
+}
+
+// Formats accepted by NewReader
+const (
+	FormatCSV     = "csv"
+	FormatARC     = "arc"
+	FormatTwitter = "twitter"
+)
+
+// NewReader returns a Reader for a trace in format, one of FormatCSV,
+// FormatARC or FormatTwitter
+func NewReader(format string, r io.Reader) (Reader, error) {
+	switch format {
+	case FormatCSV:
+		return NewCSVReader(r), nil
+	case FormatARC:
+		return NewARCReader(r, 1), nil
+	case FormatTwitter:
+		return NewTwitterReader(r), nil
+	default:
+		return nil, fmt.Errorf("simulate: unknown trace format %q", format)
+	}
+}
+
+// NewCSVReader reads "key[,size[,op]]" records, op being get, set or delete.
+// size defaults to 1 and op to get; lines starting with # are skipped.
+func NewCSVReader(r io.Reader) Reader {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.Comment = '#'
+	cr.ReuseRecord = true
+	return &csvReader{r: cr}
+}
+
+type csvReader struct {
+	r *csv.Reader
+}
+
+func (c *csvReader) Next() (Access, error) {
+	rec, err := c.r.Read()
+	if err != nil {
+		return Access{}, err
+	}
+	a := Access{Key: rec[0], Size: 1}
+	if len(rec) > 1 && rec[1] != "" {
+		if a.Size, err = strconv.Atoi(rec[1]); err != nil || a.Size < 0 {
+			return Access{}, c.errorf("invalid size %q", rec[1])
+		}
+	}
+	if len(rec) > 2 {
+		switch strings.ToLower(rec[2]) {
+		case "get", "":
+		case "set":
+			a.Op = OpSet
+		case "delete", "del":
+			a.Op = OpDelete
+		default:
+			return Access{}, c.errorf("invalid op %q", rec[2])
+		}
+	}
+	return a, nil
+}
+
+func (c *csvReader) errorf(format string, args ...interface{}) error {
+	line, _ := c.r.FieldPos(0)
+	return fmt.Errorf("simulate: csv line %d: %s", line, fmt.Sprintf(format, args...))
+}
+
+// NewARCReader reads the block traces of the ARC paper (Megiddo and Modha),
+// lines of "start count ignored request": every block from start to
+// start+count-1 is read once, with a value of blockSize bytes
+func NewARCReader(r io.Reader, blockSize int) Reader {
+	return &arcReader{s: bufio.NewScanner(r), size: blockSize}
+}
+
+type arcReader struct {
+	s          *bufio.Scanner
+	size       int
+	line       int
+	next, left int64 // blocks of the current line still to yield
+}
+
+func (a *arcReader) Next() (Access, error) {
+	for a.left == 0 {
+		if !a.s.Scan() {
+			if err := a.s.Err(); err != nil {
+				return Access{}, err
+			}
+			return Access{}, io.EOF
+		}
+		a.line++
+		fields := strings.Fields(a.s.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) < 2 {
+			return Access{}, fmt.Errorf("simulate: arc line %d: want at least 2 fields, got %d", a.line, len(fields))
+		}
+		start, err1 := strconv.ParseInt(fields[0], 10, 64)
+		count, err2 := strconv.ParseInt(fields[1], 10, 64)
+		if err1 != nil || err2 != nil || count < 0 {
+			return Access{}, fmt.Errorf("simulate: arc line %d: invalid block range", a.line)
+		}
+		a.next, a.left = start, count
+	}
+	key := strconv.FormatInt(a.next, 10)
+	a.next++
+	a.left--
+	return Access{Key: key, Size: a.size}, nil
+}
+
+// NewTwitterReader reads the cache traces Twitter published for its memcached
+// clusters: "timestamp,key,key size,value size,client,operation,ttl". Reads
+// (get, gets) become OpGet, writes (set, add, replace, cas, append, prepend,
+// incr, decr) OpSet and delete OpDelete. TTLs are ignored.
+func NewTwitterReader(r io.Reader) Reader {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.ReuseRecord = true
+	return &twitterReader{r: cr}
+}
+
+type twitterReader struct {
+	r *csv.Reader
+}
+
+func (t *twitterReader) Next() (Access, error) {
+	rec, err := t.r.Read()
+	if err != nil {
+		return Access{}, err
+	}
+	line, _ := t.r.FieldPos(0)
+	if len(rec) < 7 {
+		return Access{}, fmt.Errorf("simulate: twitter line %d: want 7 fields, got %d", line, len(rec))
+	}
+	size, err := strconv.Atoi(rec[3])
+	if err != nil || size < 0 {
+		return Access{}, fmt.Errorf("simulate: twitter line %d: invalid value size %q", line, rec[3])
+	}
+	a := Access{Key: rec[1], Size: size}
+	switch rec[5] {
+	case "get", "gets":
+	case "set", "add", "replace", "cas", "append", "prepend", "incr", "decr":
+		a.Op = OpSet
+	case "delete":
+		a.Op = OpDelete
+	default:
+		return Access{}, fmt.Errorf("simulate: twitter line %d: invalid operation %q", line, rec[5])
+	}
+	return a, nil
+}
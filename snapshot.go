@@ -0,0 +1,220 @@
+package LCache_go
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"lcache/store"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+)
+
+// snapshot format: magic header followed by one record per entry,
+// uvarint(len(key)) key uvarint(len(value)) value varint(expiresAt unix nanos, 0 = never)
+
+const snapshotMagic = "LCSNAP1\n"
+
+var errBadSnapshot = errors.New("lcache: malformed snapshot")
+
+// lengths read from a snapshot are checked before allocating: keys against
+// maxSnapshotKey, values against MaxBytes, or maxSnapshotValue without one
+const (
+	maxSnapshotKey   = 1024 * 1024
+	maxSnapshotValue = 1024 * 1024 * 1024
+)
+
+// snapshotValueLimit is the largest value length readSnapshot accepts
+func (c *Cache) snapshotValueLimit() uint64 {
+	if maxBytes := atomic.LoadInt64(&c.maxBytes); maxBytes > 0 {
+		return uint64(maxBytes)
+	}
+	return maxSnapshotValue
+}
+
+// SaveSnapshot writes every live entry to w
+func (c *Cache) SaveSnapshot(w io.Writer) error {
+	if !OpenedAndInitialized(c) {
+		return ErrCacheClosed
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.store == nil {
+		return ErrCacheClosed
+	}
+	return writeSnapshot(w, c.store)
+}
+
+// WriteSnapshot saves a snapshot to SnapshotPath
+func (c *Cache) WriteSnapshot() error {
+	if c.opts.SnapshotPath == "" {
+		return errors.New("lcache: SnapshotPath is not configured")
+	}
+	if !OpenedAndInitialized(c) {
+		return ErrCacheClosed
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.store == nil {
+		return ErrCacheClosed
+	}
+	return c.saveSnapshotFile(c.opts.SnapshotPath, c.store)
+}
+
+// LoadSnapshot adds the entries read from r to the cache, skipping those already expired
+func (c *Cache) LoadSnapshot(r io.Reader) error {
+	if !OpenedAndInitialized(c) {
+		return ErrCacheClosed
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.store == nil {
+		return ErrCacheClosed
+	}
+	return readSnapshot(r, c.store, c.snapshotValueLimit())
+}
+
+func writeSnapshot(w io.Writer, s store.Store) error {
+	bw := bufio.NewWriter(w)
+	if _, err := bw.WriteString(snapshotMagic); err != nil {
+		return err
+	}
+
+	var err error
+	buf := make([]byte, binary.MaxVarintLen64)
+	putUvarint := func(v uint64) {
+		if err == nil {
+			_, err = bw.Write(buf[:binary.PutUvarint(buf, v)])
+		}
+	}
+	s.Range(func(key string, value store.Value, expiresAt time.Time) bool {
+		bv, ok := value.(ByteView)
+		if !ok {
+			return true
+		}
+		var expires int64
+		if !expiresAt.IsZero() {
+			expires = expiresAt.UnixNano()
+		}
+		putUvarint(uint64(len(key)))
+		if err == nil {
+			_, err = bw.WriteString(key)
+		}
+		putUvarint(uint64(len(bv.b)))
+		if err == nil {
+			_, err = bw.Write(bv.b)
+		}
+		if err == nil {
+			_, err = bw.Write(buf[:binary.PutVarint(buf, expires)])
+		}
+		return err == nil
+	})
+	if err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+type snapshotEntry struct {
+	key       string
+	value     ByteView
+	expiresAt int64
+}
+
+func readSnapshot(r io.Reader, s store.Store, maxValue uint64) error {
+	br := bufio.NewReader(r)
+	magic := make([]byte, len(snapshotMagic))
+	if _, err := io.ReadFull(br, magic); err != nil || string(magic) != snapshotMagic {
+		return errBadSnapshot
+	}
+
+	var entries []snapshotEntry
+	for {
+		keyLen, err := binary.ReadUvarint(br)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return errBadSnapshot
+		}
+		if keyLen > maxSnapshotKey {
+			return fmt.Errorf("%w: key length %d", errBadSnapshot, keyLen)
+		}
+		key := make([]byte, keyLen)
+		if _, err := io.ReadFull(br, key); err != nil {
+			return errBadSnapshot
+		}
+		valLen, err := binary.ReadUvarint(br)
+		if err != nil {
+			return errBadSnapshot
+		}
+		if valLen > maxValue {
+			return fmt.Errorf("%w: value length %d of %q", errBadSnapshot, valLen, key)
+		}
+		val := make([]byte, valLen)
+		if _, err := io.ReadFull(br, val); err != nil {
+			return errBadSnapshot
+		}
+		expires, err := binary.ReadVarint(br)
+		if err != nil {
+			return errBadSnapshot
+		}
+		entries = append(entries, snapshotEntry{key: string(key), value: ByteView{b: val}, expiresAt: expires})
+	}
+
+	// entries were written most recently used first, insert in reverse to keep the order
+	now := time.Now()
+	for i := len(entries) - 1; i >= 0; i-- {
+		e := entries[i]
+		var ttl time.Duration
+		if e.expiresAt != 0 {
+			if ttl = time.Unix(0, e.expiresAt).Sub(now); ttl <= 0 {
+				continue
+			}
+		}
+		if err := s.SetWithExpiration(e.key, e.value, ttl); err != nil {
+			return fmt.Errorf("lcache: restore %q: %w", e.key, err)
+		}
+	}
+	return nil
+}
+
+// saveSnapshotFile atomically replaces path with a snapshot of s
+func (c *Cache) saveSnapshotFile(path string, s store.Store) (err error) {
+	defer func() { c.snapshots.recordSave(err) }()
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer c.res.acquire(resFile, "snapshot")()
+	defer os.Remove(tmp.Name())
+
+	if err := writeSnapshot(tmp, s); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// loadSnapshotFile restores s from path, a missing file is not an error
+func (c *Cache) loadSnapshotFile(path string, s store.Store) error {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		c.snapshots.recordRestore(err)
+		return err
+	}
+	defer c.res.acquire(resFile, "snapshot")()
+	defer f.Close()
+	err = readSnapshot(f, s, c.snapshotValueLimit())
+	c.snapshots.recordRestore(err)
+	return err
+}
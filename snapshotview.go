@@ -0,0 +1,85 @@
+package LCache_go
+
+import (
+	"lcache/store"
+	"time"
+)
+
+// SnapshotView is an immutable point-in-time view of the cache, for analytics
+// jobs that read large parts of it. Values are shared with the cache rather than
+// copied, so taking a view costs one copy of the index made under the store's
+// read lock; after that, reading the view never blocks writers. Entries that
+// were live when the view was taken stay visible even after they expire.
+
+type SnapshotView struct {
+	taken     time.Time
+	keys      []string // most recently used first
+	entries   map[string]viewEntry
+	usedBytes int64
+}
+
+type viewEntry struct {
+	value     ByteView
+	expiresAt time.Time
+}
+
+// SnapshotView captures the live entries of the cache, a closed cache gives an empty view
+func (c *Cache) SnapshotView() *SnapshotView {
+	v := &SnapshotView{taken: time.Now(), entries: make(map[string]viewEntry)}
+	c.rangeEntries(func(key string, value store.Value, expiresAt time.Time) bool {
+		bv, _ := value.(ByteView)
+		bv, err := c.decodeValue(key, bv)
+		if err != nil {
+			return true
+		}
+		v.keys = append(v.keys, key)
+		v.entries[key] = viewEntry{value: bv, expiresAt: expiresAt}
+		v.usedBytes += int64(bv.Len())
+		return true
+	})
+	return v
+}
+
+// Taken returns when the view was captured
+func (v *SnapshotView) Taken() time.Time {
+	return v.taken
+}
+
+func (v *SnapshotView) Len() int {
+	return len(v.keys)
+}
+
+// UsedBytes returns the total size of the values in the view
+func (v *SnapshotView) UsedBytes() int64 {
+	return v.usedBytes
+}
+
+func (v *SnapshotView) Get(key string) (ByteView, bool) {
+	e, ok := v.entries[key]
+	return e.value, ok
+}
+
+// Inspect returns the metadata of key as of when the view was taken
+func (v *SnapshotView) Inspect(key string) (EntryInfo, bool) {
+	e, ok := v.entries[key]
+	if !ok {
+		return EntryInfo{}, false
+	}
+	return newEntryInfo(key, e.value, e.expiresAt), true
+}
+
+// Keys returns the keys in the view, most recently used first
+func (v *SnapshotView) Keys() []string {
+	return append([]string(nil), v.keys...)
+}
+
+// Range calls fn for every entry, most recently used first, until fn returns
+// false. Unlike store.Store.Range, fn may call back into the cache.
+func (v *SnapshotView) Range(fn func(info EntryInfo, value ByteView) bool) {
+	for _, key := range v.keys {
+		e := v.entries[key]
+		if !fn(newEntryInfo(key, e.value, e.expiresAt), e.value) {
+			return
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package LCache_go
+
+import (
+	"time"
+)
+
+// point-in-time statistics snapshot, see Cache.Stats for the keys
+
+type Stats map[string]interface{}
+
+const defaultStatsInterval = time.Minute
+
+// startStatsReporter periodically hands a Stats snapshot to opts.StatsReporter, need to hold the lock
+func (c *Cache) startStatsReporter() {
+	interval := c.opts.StatsInterval
+	if interval <= 0 {
+		interval = defaultStatsInterval
+	}
+	stop := make(chan struct{})
+	c.statsStop = stop
+
+	done := c.res.acquire(resGoroutine, "stats_reporter")
+	go func() {
+		defer done()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		defer c.res.acquire(resTicker, "stats_reporter")()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				c.opts.StatsReporter(c.Stats())
+			}
+		}
+	}()
+}
+
+// stopStatsReporter stops the reporter goroutine if running, need to hold the lock
+func (c *Cache) stopStatsReporter() {
+	if c.statsStop != nil {
+		close(c.statsStop)
+		c.statsStop = nil
+	}
+}
@@ -0,0 +1,65 @@
+package store
+
+// Capabilities describes what a store supports, so callers can feature-detect
+// instead of finding out from silently ignored settings
+
+type Capabilities struct {
+	TTL          bool // honors expirations passed to SetWithExpiration and Expire
+	Tags         bool // can group entries under tags
+	Iteration    bool // Range and CountPrefix see every live entry
+	Persistent   bool // entries survive a restart of the process
+	Events       bool // reports changes, see Notifier
+	Versions     bool // see Versioner
+	Transactions bool // see Transactor
+	Quotas       bool // see QuotaStore
+	Pinning      bool // see Pinner
+	Pausing      bool // see Pauser
+	Renaming     bool // see Renamer
+}
+
+// Capable is implemented by stores that describe their own capabilities
+type Capable interface {
+	Capabilities() Capabilities
+}
+
+// CapabilitiesOf returns what s supports: its own description if it is Capable,
+// otherwise an in-memory store with TTL and iteration whose optional interfaces
+// tell the rest
+func CapabilitiesOf(s Store) Capabilities {
+	if c, ok := s.(Capable); ok {
+		return c.Capabilities()
+	}
+	return interfaceCapabilities(s, Capabilities{TTL: true, Iteration: true})
+}
+
+// interfaceCapabilities fills in the capabilities implied by the optional interfaces of s
+func interfaceCapabilities(s interface{}, c Capabilities) Capabilities {
+	_, c.Events = s.(Notifier)
+	_, c.Versions = s.(Versioner)
+	_, c.Transactions = s.(Transactor)
+	_, c.Quotas = s.(QuotaStore)
+	_, c.Pinning = s.(Pinner)
+	_, c.Pausing = s.(Pauser)
+	_, c.Renaming = s.(Renamer)
+	return c
+}
+
+func (l *lRUStore) Capabilities() Capabilities {
+	return interfaceCapabilities(l, Capabilities{TTL: true, Iteration: true})
+}
+
+// Capabilities are those of the adapted Store
+func (u *upgraded) Capabilities() Capabilities {
+	return CapabilitiesOf(u.s)
+}
+
+// Capabilities are those the StoreV2 declares if it is Capable, otherwise TTL
+// and iteration. Optional interfaces of the StoreV2 aren't reachable through
+// the adapter, so the capabilities they stand for are cleared.
+func (d *downgraded) Capabilities() Capabilities {
+	c := Capabilities{TTL: true, Iteration: true}
+	if cs, ok := d.s.(Capable); ok {
+		c = cs.Capabilities()
+	}
+	return interfaceCapabilities(d, c)
+}
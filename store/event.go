@@ -0,0 +1,46 @@
+package store
+
+// EventType says what happened to an entry
+
+type EventType int
+
+const (
+	EventSet    EventType = iota + 1 // a new key was stored
+	EventUpdate                      // an existing key got a new value
+	EventDelete                      // removed by Delete
+	EventEvict                       // removed to make room, or by Trim
+	EventExpire                      // removed because its ttl passed
+	EventClear                       // every entry was removed, Key is empty
+)
+
+func (t EventType) String() string {
+	switch t {
+	case EventSet:
+		return "set"
+	case EventUpdate:
+		return "update"
+	case EventDelete:
+		return "delete"
+	case EventEvict:
+		return "evict"
+	case EventExpire:
+		return "expire"
+	case EventClear:
+		return "clear"
+	default:
+		return "unknown"
+	}
+}
+
+type Event struct {
+	Type  EventType
+	Key   string
+	Value Value // the new value for set and update, the removed one otherwise, nil for clear
+}
+
+// Notifier is implemented by stores that report changes as they happen. The
+// listener runs under the store lock, so it must not block or call back into
+// the store.
+type Notifier interface {
+	SetListener(fn func(Event))
+}
@@ -0,0 +1,637 @@
+package store
+
+import (
+	"container/list"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+var _ Store = (*Fake)(nil)
+
+// Fake is a deterministic in-memory Store for tests. It runs on its own clock,
+// which only moves when Advance is called, never evicts or expires anything
+// behind the caller's back, and exposes its internals for assertions.
+type Fake struct {
+	mu        sync.Mutex
+	now       time.Time
+	list      *list.List // most recently used at the front
+	items     map[string]*list.Element
+	expires   map[string]time.Time
+	maxBytes  int64
+	usedBytes int64
+	evicted   []string
+	expired   []string
+	onEvicted func(key string, value Value)
+	listener  func(Event)
+	version   uint64
+	pins      pinSet
+	closed    bool
+
+	paused     bool
+	pauseLimit int64
+}
+
+// NewFake returns an empty Fake without a byte budget whose clock starts at the Unix epoch
+func NewFake() *Fake {
+	return &Fake{
+		now:     time.Unix(0, 0),
+		list:    list.New(),
+		items:   make(map[string]*list.Element),
+		expires: make(map[string]time.Time),
+	}
+}
+
+// SetOnEvicted installs a callback fired for capacity and forced evictions
+func (f *Fake) SetOnEvicted(fn func(key string, value Value)) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.onEvicted = fn
+}
+
+func (f *Fake) SetListener(fn func(Event)) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.listener = fn
+}
+
+func (f *Fake) Get(key string) (Value, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	elem, ok := f.items[key]
+	if !ok {
+		return nil, false
+	}
+	f.list.MoveToFront(elem)
+	return elem.Value.(*lruEntry).value, true
+}
+
+func (f *Fake) Peek(key string) (Value, time.Time, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	elem, ok := f.items[key]
+	if !ok {
+		return nil, time.Time{}, false
+	}
+	return elem.Value.(*lruEntry).value, f.expires[key], true
+}
+
+func (f *Fake) Has(key string) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	_, ok := f.items[key]
+	return ok
+}
+
+func (f *Fake) CountPrefix(prefix string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	count := 0
+	for key := range f.items {
+		if strings.HasPrefix(key, prefix) {
+			count++
+		}
+	}
+	return count
+}
+
+func (f *Fake) Set(key string, value Value) error {
+	return f.SetWithExpiration(key, value, 0)
+}
+
+func (f *Fake) SetWithExpiration(key string, value Value, expiration time.Duration) error {
+	if value == nil {
+		f.Delete(key)
+		return nil
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.setLocked(key, value, expiration)
+	f.evictOverBudget()
+	return nil
+}
+
+func (f *Fake) setLocked(key string, value Value, expiration time.Duration) {
+	if elem, ok := f.items[key]; ok {
+		entry := elem.Value.(*lruEntry)
+		f.usedBytes += int64(value.Len() - entry.value.Len())
+		entry.value = value
+		f.version++
+		entry.version = f.version
+		f.list.MoveToFront(elem)
+		f.emit(EventUpdate, key, value)
+	} else {
+		f.version++
+		f.items[key] = f.list.PushFront(&lruEntry{key: key, value: value, version: f.version})
+		f.usedBytes += int64(value.Len())
+		f.emit(EventSet, key, value)
+	}
+	// like lRUStore, an update without expiration keeps the current one
+	if expiration > 0 {
+		f.expires[key] = f.now.Add(expiration)
+	}
+}
+
+func (f *Fake) Delete(key string) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.deleteLocked(key)
+}
+
+func (f *Fake) deleteLocked(key string) bool {
+	elem, ok := f.items[key]
+	if ok {
+		f.removeElement(elem)
+		f.emit(EventDelete, key, elem.Value.(*lruEntry).value)
+	}
+	return ok
+}
+
+func (f *Fake) Expire(key string, expiration time.Duration) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if _, ok := f.items[key]; !ok {
+		return false
+	}
+	if expiration > 0 {
+		f.expires[key] = f.now.Add(expiration)
+	} else {
+		delete(f.expires, key)
+	}
+	return true
+}
+
+func (f *Fake) Clear() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.list.Init()
+	f.items = make(map[string]*list.Element)
+	f.expires = make(map[string]time.Time)
+	f.usedBytes = 0
+	f.emit(EventClear, "", nil)
+}
+
+func (f *Fake) Len() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.list.Len()
+}
+
+func (f *Fake) UsedBytes() int64 {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.usedBytes
+}
+
+func (f *Fake) MaxBytes() int64 {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.maxBytes
+}
+
+func (f *Fake) SetMaxBytes(maxBytes int64) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.maxBytes = maxBytes
+	f.evictOverBudget()
+}
+
+func (f *Fake) Trim(bytes int64) int64 {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var freed int64
+	for freed < bytes {
+		elem := f.victim()
+		if elem == nil {
+			break
+		}
+		freed += int64(elem.Value.(*lruEntry).value.Len())
+		f.evictElement(elem)
+	}
+	return freed
+}
+
+func (f *Fake) Range(fn func(key string, value Value, expiresAt time.Time) bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for elem := f.list.Front(); elem != nil; elem = elem.Next() {
+		entry := elem.Value.(*lruEntry)
+		if !fn(entry.key, entry.value, f.expires[entry.key]) {
+			return
+		}
+	}
+}
+
+func (f *Fake) Close() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.closed = true
+}
+
+func (f *Fake) GetVersion(key string) (Value, uint64, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	elem, ok := f.items[key]
+	if !ok {
+		return nil, 0, false
+	}
+	f.list.MoveToFront(elem)
+	entry := elem.Value.(*lruEntry)
+	return entry.value, entry.version, true
+}
+
+func (f *Fake) SetIfVersion(key string, value Value, expiration time.Duration, version uint64) (uint64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	elem, ok := f.items[key]
+	var have uint64
+	if ok {
+		have = elem.Value.(*lruEntry).version
+	}
+	if err := checkVersion(version, have, ok); err != nil {
+		return have, err
+	}
+	f.setLocked(key, value, expiration)
+	newVersion := f.version
+	f.evictOverBudget()
+	return newVersion, nil
+}
+
+func (f *Fake) DeleteIfVersion(key string, version uint64) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	elem, ok := f.items[key]
+	if !ok {
+		return ErrNotFound
+	}
+	if err := checkVersion(version, elem.Value.(*lruEntry).version, true); err != nil {
+		return err
+	}
+	f.deleteLocked(key)
+	return nil
+}
+
+func (f *Fake) Rename(oldKey, newKey string, overwrite bool) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	elem, ok := f.items[oldKey]
+	if !ok {
+		return ErrNotFound
+	}
+	if oldKey == newKey {
+		return nil
+	}
+	target, exists := f.items[newKey]
+	if exists && !overwrite {
+		return ErrKeyExists
+	}
+	if exists {
+		f.removeElement(target)
+	}
+
+	entry := elem.Value.(*lruEntry)
+	expiresAt, hasExpiry := f.expires[oldKey]
+	delete(f.items, oldKey)
+	delete(f.expires, oldKey)
+	entry.key = newKey
+	f.version++
+	entry.version = f.version
+	f.items[newKey] = elem
+	if hasExpiry {
+		f.expires[newKey] = expiresAt
+	}
+
+	f.emit(EventDelete, oldKey, entry.value)
+	if exists {
+		f.emit(EventUpdate, newKey, entry.value)
+	} else {
+		f.emit(EventSet, newKey, entry.value)
+	}
+	return nil
+}
+
+func (f *Fake) Update(fn func(tx Tx) error) (err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	tx := &fakeTx{f: f}
+	listener := f.listener
+	f.listener = func(e Event) { tx.events = append(tx.events, e) }
+	defer func() {
+		if r := recover(); r != nil {
+			tx.rollback()
+			f.listener = listener
+			panic(r)
+		}
+		if err != nil {
+			tx.rollback()
+		}
+		f.listener = listener
+		if err == nil {
+			for _, e := range tx.events {
+				f.emit(e.Type, e.Key, e.Value)
+			}
+			f.evictOverBudget()
+		}
+	}()
+	return fn(tx)
+}
+
+type fakeTx struct {
+	f      *Fake
+	undo   []txUndo
+	events []Event
+}
+
+func (t *fakeTx) Get(key string) (Value, time.Time, bool) {
+	elem, ok := t.f.items[key]
+	if !ok {
+		return nil, time.Time{}, false
+	}
+	return elem.Value.(*lruEntry).value, t.f.expires[key], true
+}
+
+func (t *fakeTx) Set(key string, value Value, expiration time.Duration) error {
+	if value == nil {
+		t.Delete(key)
+		return nil
+	}
+	t.save(key)
+	expiresAt, hadExpiry := t.f.expires[key]
+	t.f.setLocked(key, value, expiration)
+	if expiration == 0 && hadExpiry {
+		t.f.expires[key] = expiresAt
+	}
+	return nil
+}
+
+func (t *fakeTx) Delete(key string) bool {
+	t.save(key)
+	return t.f.deleteLocked(key)
+}
+
+func (t *fakeTx) save(key string) {
+	u := txUndo{key: key}
+	if elem, ok := t.f.items[key]; ok {
+		entry := elem.Value.(*lruEntry)
+		u.value, u.version, u.expiresAt, u.existed = entry.value, entry.version, t.f.expires[key], true
+	}
+	t.undo = append(t.undo, u)
+}
+
+func (t *fakeTx) rollback() {
+	for i := len(t.undo) - 1; i >= 0; i-- {
+		u := t.undo[i]
+		if !u.existed {
+			t.f.deleteLocked(u.key)
+			continue
+		}
+		t.f.setLocked(u.key, u.value, 0)
+		t.f.items[u.key].Value.(*lruEntry).version = u.version
+		if !u.expiresAt.IsZero() {
+			t.f.expires[u.key] = u.expiresAt
+		}
+	}
+}
+
+// inspection and control
+
+// Now returns the fake clock
+func (f *Fake) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.now
+}
+
+// Advance moves the clock forward by d and removes every entry whose expiration
+// has passed, returning their keys in expiration order. Nothing is removed while
+// the fake is paused.
+func (f *Fake) Advance(d time.Duration) []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.now = f.now.Add(d)
+	if f.paused {
+		return nil
+	}
+	return f.expireDue()
+}
+
+// expireDue removes the entries whose expiration has passed
+func (f *Fake) expireDue() []string {
+	var expired []string
+	for elem := f.list.Back(); elem != nil; {
+		prev := elem.Prev()
+		key := elem.Value.(*lruEntry).key
+		if at, ok := f.expires[key]; ok && !at.After(f.now) {
+			expired = append(expired, key)
+		}
+		elem = prev
+	}
+	sortByExpiry(expired, f.expires)
+	for _, key := range expired {
+		elem := f.items[key]
+		f.removeElement(elem)
+		f.emit(EventExpire, key, elem.Value.(*lruEntry).value)
+	}
+	f.expired = append(f.expired, expired...)
+	return expired
+}
+
+// Keys returns the keys in eviction order, the next victim first
+func (f *Fake) Keys() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	keys := make([]string, 0, f.list.Len())
+	for elem := f.list.Back(); elem != nil; elem = elem.Prev() {
+		keys = append(keys, elem.Value.(*lruEntry).key)
+	}
+	return keys
+}
+
+// Expiries returns a copy of the expiry table
+func (f *Fake) Expiries() map[string]time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	expires := make(map[string]time.Time, len(f.expires))
+	for k, v := range f.expires {
+		expires[k] = v
+	}
+	return expires
+}
+
+func (f *Fake) Counters() map[string]int64 {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return map[string]int64{
+		"evictions":   int64(len(f.evicted)),
+		"expirations": int64(len(f.expired)),
+	}
+}
+
+// Evicted returns the keys evicted so far, oldest eviction first
+func (f *Fake) Evicted() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.evicted...)
+}
+
+// Expired returns the keys removed by Advance so far
+func (f *Fake) Expired() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.expired...)
+}
+
+// EvictOldest forces eviction of the least recently used entry that isn't pinned
+func (f *Fake) EvictOldest() (string, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	elem := f.victim()
+	if elem == nil {
+		return "", false
+	}
+	key := elem.Value.(*lruEntry).key
+	f.evictElement(elem)
+	return key, true
+}
+
+// Evict forces eviction of key as if the store ran out of space, even if it is pinned
+func (f *Fake) Evict(key string) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	elem, ok := f.items[key]
+	if ok {
+		f.evictElement(elem)
+	}
+	return ok
+}
+
+// IsClosed reports whether Close has been called
+func (f *Fake) IsClosed() bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.closed
+}
+
+func (f *Fake) Pin(key string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.pins.pin(key)
+}
+
+func (f *Fake) Unpin(key string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.pins.unpin(key)
+	f.evictOverBudget()
+}
+
+func (f *Fake) Pause(limit int64) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.paused = true
+	f.pauseLimit = limit
+}
+
+func (f *Fake) Resume() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if !f.paused {
+		return
+	}
+	f.paused = false
+	f.expireDue()
+	f.evictOverBudget()
+}
+
+// Paused reports whether eviction and expiry are paused
+func (f *Fake) Paused() bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.paused
+}
+
+// Pinned reports whether key is currently pinned
+func (f *Fake) Pinned(key string) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.pins[key] > 0
+}
+
+func (f *Fake) victim() *list.Element {
+	for elem := f.list.Back(); elem != nil; elem = elem.Prev() {
+		if f.pins[elem.Value.(*lruEntry).key] == 0 {
+			return elem
+		}
+	}
+	return nil
+}
+
+func (f *Fake) evictOverBudget() {
+	budget := f.maxBytes
+	if f.paused {
+		if f.pauseLimit <= 0 {
+			return
+		}
+		budget = f.pauseLimit
+	}
+	for f.maxBytes > 0 && f.usedBytes > budget {
+		elem := f.victim()
+		if elem == nil {
+			return
+		}
+		f.evictElement(elem)
+	}
+}
+
+func (f *Fake) evictElement(elem *list.Element) {
+	entry := elem.Value.(*lruEntry)
+	f.removeElement(elem)
+	f.evicted = append(f.evicted, entry.key)
+	f.emit(EventEvict, entry.key, entry.value)
+	if f.onEvicted != nil {
+		f.onEvicted(entry.key, entry.value)
+	}
+}
+
+func (f *Fake) emit(t EventType, key string, value Value) {
+	if f.listener != nil {
+		f.listener(Event{Type: t, Key: key, Value: value})
+	}
+}
+
+func (f *Fake) removeElement(elem *list.Element) {
+	entry := elem.Value.(*lruEntry)
+	f.list.Remove(elem)
+	delete(f.items, entry.key)
+	delete(f.expires, entry.key)
+	f.usedBytes -= int64(entry.value.Len())
+}
+
+// sortByExpiry orders keys by deadline, keeping the given order for ties
+func sortByExpiry(keys []string, expires map[string]time.Time) {
+	sort.SliceStable(keys, func(i, j int) bool {
+		return expires[keys[i]].Before(expires[keys[j]])
+	})
+}
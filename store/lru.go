@@ -2,7 +2,10 @@ package store
 
 import (
 	"container/list"
+	"runtime"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -14,14 +17,47 @@ type lRUStore struct {
 	maxBytes        int64
 	usedBytes       int64
 	cleanupInterval time.Duration
-	cleanupTicker   *time.Ticker
+	cleanupTicker   *time.Ticker // nil when cleanup is disabled
 	closeCh         chan bool
+	closed          bool
 	onEvicted       func(key string, value Value)
+	listener        func(Event)
+	quotas          quotaTracker
+	evictions       int64
+	expirations     int64
+	version         uint64 // last version handed out
+	pins            pinSet
+	cleanupRuns     int64
+	cleanupExpired  int64         // entries removed by the cleanup loop
+	cleanupExamined int64         // TTL'd entries the cleanup loop looked at
+	cleanupTime     time.Duration // spent in the cleanup loop
+	lastCleanup     cleanupStats
+	cleanupDelay    time.Duration // current cleanup interval
+	adaptiveCleanup bool
+	evictionBatch   int           // most entries evict removes per lock hold
+	evictionBatches int64         // lock releases taken to work off a backlog
+	evicting        bool          // a background evictAll is running
+	evictCh         chan struct{} // wakes the evictor, nil unless AsyncEviction is set
+	highWatermark   float64
+	lowWatermark    float64
+	evictorRuns     int64
+	paused          bool
+	pauseLimit      int64 // safety cap while paused, 0 means none
+	goroutines      int64 // background goroutines running, updated atomically
+}
+
+// cleanupStats describes one cleanup pass
+
+type cleanupStats struct {
+	examined int
+	removed  int
+	took     time.Duration
 }
 
 type lruEntry struct {
-	key   string
-	value Value
+	key     string
+	value   Value
+	version uint64
 }
 
 func newLRUStore(opt Options) *lRUStore {
@@ -32,36 +68,108 @@ func newLRUStore(opt Options) *lRUStore {
 		expires:         make(map[string]time.Time),
 		maxBytes:        opt.MaxBytes,
 		cleanupInterval: opt.CleanupInterval,
+		cleanupDelay:    opt.CleanupInterval,
+		adaptiveCleanup: opt.AdaptiveCleanup,
 		closeCh:         make(chan bool),
-		cleanupTicker:   time.NewTicker(opt.CleanupInterval),
 		onEvicted:       opt.OnEvicted,
+		evictionBatch:   opt.EvictionBatch,
+	}
+	if store.evictionBatch == 0 {
+		store.evictionBatch = DefaultEvictionBatch
+	}
+	if opt.AsyncEviction {
+		store.evictCh = make(chan struct{}, 1)
+		store.highWatermark, store.lowWatermark = opt.watermarks()
+		store.spawn(store.evictor)
 	}
 
-	go store.CleanupStore()
+	// a zero interval disables the cleanup loop, expired entries are then only
+	// removed when a write triggers evict
+	if opt.CleanupInterval > 0 {
+		store.cleanupTicker = time.NewTicker(opt.CleanupInterval)
+		store.spawn(store.CleanupStore)
+	}
 
 	return store
 }
 
 func (l *lRUStore) Get(key string) (Value, bool) {
-	l.mu.RLocker()
+	l.mu.RLock()
 	elem, ok := l.items[key]
 	if !ok {
 		l.mu.RUnlock()
 		return nil, false
 	}
 	value := elem.Value.(*lruEntry).value
+	_, live := l.live(key)
 	l.mu.RUnlock()
 
 	// lru strategy: 将访问的元素移动到链表头部
 	l.mu.Lock()
+	defer l.mu.Unlock()
+	if !live {
+		l.expireLazily(key)
+		return nil, false
+	}
 	if _, ok := l.items[key]; ok {
 		l.list.MoveToFront(elem)
 	}
-	l.mu.Unlock()
-
 	return value, true
 }
 
+func (l *lRUStore) Peek(key string) (Value, time.Time, bool) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	elem, ok := l.items[key]
+	if !ok {
+		return nil, time.Time{}, false
+	}
+	expiresAt := l.expires[key]
+	if !expiresAt.IsZero() && expiresAt.Before(time.Now()) {
+		return nil, time.Time{}, false
+	}
+	return elem.Value.(*lruEntry).value, expiresAt, true
+}
+
+func (l *lRUStore) Has(key string) bool {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	_, ok := l.live(key)
+	return ok
+}
+
+func (l *lRUStore) CountPrefix(prefix string) int {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	if prefix == "" {
+		return len(l.items) - l.expiredCount()
+	}
+	now := time.Now()
+	count := 0
+	for key := range l.items {
+		if strings.HasPrefix(key, prefix) {
+			if expiresAt := l.expires[key]; expiresAt.IsZero() || !expiresAt.Before(now) {
+				count++
+			}
+		}
+	}
+	return count
+}
+
+// expiredCount counts entries past their expiration not yet cleaned up, need to hold the lock
+func (l *lRUStore) expiredCount() int {
+	now := time.Now()
+	n := 0
+	for _, expiresAt := range l.expires {
+		if expiresAt.Before(now) {
+			n++
+		}
+	}
+	return n
+}
+
 func (l *lRUStore) Set(key string, value Value) error {
 	return l.SetWithExpiration(key, value, 0)
 }
@@ -75,27 +183,48 @@ func (l *lRUStore) SetWithExpiration(key string, value Value, expiration time.Du
 	l.mu.Lock()
 	defer l.mu.Unlock()
 
+	if err := l.setLocked(key, value, expiration); err != nil {
+		return err
+	}
+	l.afterWrite()
+	return nil
+}
+
+// setLocked stores value without evicting, need to hold the lock
+func (l *lRUStore) setLocked(key string, value Value, expiration time.Duration) error {
 	if elem, ok := l.items[key]; ok {
 		// If the key already exists, update the value and move it to the front
 		oldEntry := elem.Value.(*lruEntry)
-		l.usedBytes -= int64(oldEntry.value.Len())
-		l.usedBytes += int64(value.Len())
+		delta := int64(value.Len() - oldEntry.value.Len())
+		if err := l.quotas.check(key, int64(oldEntry.value.Len()), int64(value.Len()), true); err != nil {
+			return err
+		}
+		l.usedBytes += delta
+		l.quotas.charge(key, delta, 0)
 		oldEntry.value = value
+		l.version++
+		oldEntry.version = l.version
 		l.list.MoveToFront(elem)
 		if expiration > 0 {
 			l.expires[key] = time.Now().Add(expiration)
 		}
+		l.emit(EventUpdate, key, value)
 	} else {
 		// If the key does not exist, create a new entry
-		entry := &lruEntry{key: key, value: value}
+		if err := l.quotas.check(key, 0, int64(value.Len()), false); err != nil {
+			return err
+		}
+		l.version++
+		entry := &lruEntry{key: key, value: value, version: l.version}
 		elem := l.list.PushFront(entry)
 		l.items[key] = elem
 		l.usedBytes += int64(value.Len())
+		l.quotas.charge(key, int64(value.Len()), 1)
 		if expiration > 0 {
 			l.expires[key] = time.Now().Add(expiration)
 		}
+		l.emit(EventSet, key, value)
 	}
-	l.evict()
 	return nil
 }
 
@@ -103,15 +232,32 @@ func (l *lRUStore) Delete(key string) bool {
 	l.mu.Lock()
 	defer l.mu.Unlock()
 
-	if elem, ok := l.items[key]; ok {
-		l.list.Remove(elem)
-		delete(l.items, key)
-		l.usedBytes -= int64(elem.Value.(*lruEntry).value.Len())
-		delete(l.expires, key)
-		return true
-	} else {
+	return l.deleteLocked(key)
+}
+
+// deleteLocked removes key, need to hold the lock
+func (l *lRUStore) deleteLocked(key string) bool {
+	elem, ok := l.items[key]
+	if ok {
+		l.removeElement(elem)
+		l.emit(EventDelete, key, elem.Value.(*lruEntry).value)
+	}
+	return ok
+}
+
+func (l *lRUStore) Expire(key string, expiration time.Duration) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if _, ok := l.items[key]; !ok {
 		return false
 	}
+	if expiration > 0 {
+		l.expires[key] = time.Now().Add(expiration)
+	} else {
+		delete(l.expires, key)
+	}
+	return true
 }
 
 func (l *lRUStore) Clear() {
@@ -128,6 +274,8 @@ func (l *lRUStore) Clear() {
 	l.items = make(map[string]*list.Element)
 	l.expires = make(map[string]time.Time)
 	l.usedBytes = 0
+	l.quotas.reset()
+	l.emit(EventClear, "", nil)
 }
 
 func (l *lRUStore) Len() int {
@@ -136,60 +284,624 @@ func (l *lRUStore) Len() int {
 	return l.list.Len()
 }
 
+func (l *lRUStore) UsedBytes() int64 {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.usedBytes
+}
+
+func (l *lRUStore) MaxBytes() int64 {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.maxBytes
+}
+
+func (l *lRUStore) SetMaxBytes(maxBytes int64) {
+	l.mu.Lock()
+	l.maxBytes = maxBytes
+	l.mu.Unlock()
+
+	l.evictAll()
+}
+
+func (l *lRUStore) Trim(bytes int64) int64 {
+	var freed int64
+	for freed < bytes {
+		l.mu.Lock()
+		for n := 0; freed < bytes && n < l.evictionBatch; n++ {
+			elem := l.victim()
+			if elem == nil {
+				l.mu.Unlock()
+				return freed
+			}
+			entry := elem.Value.(*lruEntry)
+			freed += int64(entry.value.Len())
+			l.removeElement(elem)
+			l.evictions++
+			l.emit(EventEvict, entry.key, entry.value)
+		}
+		l.mu.Unlock()
+	}
+	return freed
+}
+
+func (l *lRUStore) Range(fn func(key string, value Value, expiresAt time.Time) bool) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	now := time.Now()
+	for elem := l.list.Front(); elem != nil; elem = elem.Next() {
+		entry := elem.Value.(*lruEntry)
+		expiresAt := l.expires[entry.key]
+		if !expiresAt.IsZero() && expiresAt.Before(now) {
+			continue
+		}
+		if !fn(entry.key, entry.value, expiresAt) {
+			return
+		}
+	}
+}
+
 func (l *lRUStore) Close() {
 	l.mu.Lock()
 	defer l.mu.Unlock()
 
+	if l.closed {
+		return
+	}
+	l.closed = true
 	if l.cleanupTicker != nil {
 		l.cleanupTicker.Stop()
 	}
 	close(l.closeCh)
 }
 
-func (l *lRUStore) evict() {
-	// to clean up expired items and items exceeding maxBytes, need to hold the lock
-	now := time.Now()
+// evict removes expired entries and, while over budget, least recently used
+// ones, at most evictionBatch of each per call so the lock is never held for a
+// huge loop. It reports whether work is left, need to hold the lock
+func (l *lRUStore) evict() bool {
+	return l.evictDown(l.maxBytes)
+}
 
-	// Clean up expired items
+// evictDown is evict with the size loop stopping at target bytes, need to hold the lock
+func (l *lRUStore) evictDown(target int64) bool {
+	more := false
+	// expired items are left alone while maintenance is paused
+	if !l.paused {
+		_, _, more = l.sweepExpired(time.Now())
+	}
+	return l.evictSize(target) || more
+}
+
+// sweepExpired removes up to evictionBatch expired entries, returning how many
+// TTL'd entries it looked at, how many it removed and whether it stopped at the
+// batch limit, need to hold the lock
+func (l *lRUStore) sweepExpired(now time.Time) (examined, removed int, more bool) {
 	for key, expireTime := range l.expires {
-		if expireTime.Before(now) {
-			if elem, ok := l.items[key]; ok {
-				l.list.Remove(elem)
-				delete(l.items, key)
-				l.usedBytes -= int64(elem.Value.(*lruEntry).value.Len())
-				delete(l.expires, key)
-			} else {
-				delete(l.expires, key)
-			}
+		examined++
+		if !expireTime.Before(now) {
+			continue
+		}
+		if removed == l.evictionBatch {
+			return examined, removed, true
+		}
+		if elem, ok := l.items[key]; ok {
+			l.removeElement(elem)
+			l.expirations++
+			l.emit(EventExpire, key, elem.Value.(*lruEntry).value)
+			removed++
+		} else {
+			delete(l.expires, key)
 		}
 	}
-	// Clean up items exceeding maxBytes
+	return examined, removed, false
+}
+
+// evictSize evicts least recently used entries until usage is at most target,
+// at most evictionBatch per call. It reports whether work is left, need to hold the lock
+func (l *lRUStore) evictSize(target int64) bool {
+	if l.paused {
+		// only the safety cap applies while maintenance is paused
+		if l.pauseLimit <= 0 {
+			return false
+		}
+		target = l.pauseLimit
+	}
+	more := false
+	for evicted := 0; l.maxBytes > 0 && l.usedBytes > target; evicted++ {
+		elem := l.victim()
+		if elem == nil {
+			break
+		}
+		if evicted == l.evictionBatch {
+			more = true
+			break
+		}
+		entry := elem.Value.(*lruEntry)
+		l.removeElement(elem)
+		l.evictions++
+		l.emit(EventEvict, entry.key, entry.value)
+	}
+	return more
+}
+
+// evictAll runs evict in batches, releasing the lock in between so readers get in
+func (l *lRUStore) evictAll() {
 	for {
-		if l.maxBytes > 0 && l.usedBytes > l.maxBytes && l.list.Len() > 0 {
-			elem := l.list.Back()
-			if elem == nil {
-				break
+		l.mu.Lock()
+		more := !l.closed && l.evict()
+		if more {
+			l.evictionBatches++
+		}
+		l.mu.Unlock()
+		if !more {
+			return
+		}
+		runtime.Gosched()
+	}
+}
+
+// afterWrite keeps the budget after a write: inline, or by waking the evictor
+// once usage passes the high watermark. need to hold the lock
+func (l *lRUStore) afterWrite() {
+	if l.evictCh == nil || l.paused {
+		if l.evict() {
+			l.evictLater()
+		}
+		return
+	}
+	if l.maxBytes > 0 && l.usedBytes > l.watermark(l.highWatermark) {
+		select {
+		case l.evictCh <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// watermark converts a fraction of maxBytes to bytes, need to hold the lock
+func (l *lRUStore) watermark(fraction float64) int64 {
+	return int64(float64(l.maxBytes) * fraction)
+}
+
+// evictor brings usage down to the low watermark whenever afterWrite wakes it,
+// a batch at a time so writers are never blocked for long
+func (l *lRUStore) evictor() {
+	for {
+		select {
+		case <-l.closeCh:
+			return
+		case <-l.evictCh:
+		}
+		for more := true; more; runtime.Gosched() {
+			l.mu.Lock()
+			more = !l.closed && l.evictDown(l.watermark(l.lowWatermark))
+			l.mu.Unlock()
+		}
+		l.mu.Lock()
+		l.evictorRuns++
+		l.mu.Unlock()
+	}
+}
+
+// evictLater finishes an eviction backlog in the background, need to hold the lock
+func (l *lRUStore) evictLater() {
+	if l.evicting {
+		return
+	}
+	l.evicting = true
+	l.spawn(func() {
+		l.evictAll()
+		l.mu.Lock()
+		l.evicting = false
+		l.mu.Unlock()
+	})
+}
+
+// spawn runs fn on a goroutine counted in the store_goroutines counter
+func (l *lRUStore) spawn(fn func()) {
+	atomic.AddInt64(&l.goroutines, 1)
+	go func() {
+		defer atomic.AddInt64(&l.goroutines, -1)
+		fn()
+	}()
+}
+
+// victim returns the least recently used entry that isn't pinned, need to hold the lock
+func (l *lRUStore) victim() *list.Element {
+	for elem := l.list.Back(); elem != nil; elem = elem.Prev() {
+		if l.pins[elem.Value.(*lruEntry).key] == 0 {
+			return elem
+		}
+	}
+	return nil
+}
+
+func (l *lRUStore) Pin(key string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.pins.pin(key)
+}
+
+func (l *lRUStore) Unpin(key string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.pins.unpin(key)
+	l.afterWrite()
+}
+
+// removeElement unlinks an entry and updates the accounting, need to hold the lock
+func (l *lRUStore) removeElement(elem *list.Element) {
+	entry := elem.Value.(*lruEntry)
+	l.list.Remove(elem)
+	delete(l.items, entry.key)
+	delete(l.expires, entry.key)
+	l.usedBytes -= int64(entry.value.Len())
+	l.quotas.charge(entry.key, -int64(entry.value.Len()), -1)
+}
+
+func (l *lRUStore) Pause(limit int64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.paused = true
+	l.pauseLimit = limit
+}
+
+func (l *lRUStore) Resume() {
+	l.mu.Lock()
+	wasPaused := l.paused
+	l.paused = false
+	l.mu.Unlock()
+
+	if wasPaused {
+		l.evictAll()
+	}
+}
+
+func (l *lRUStore) SetListener(fn func(Event)) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.listener = fn
+}
+
+// emit reports a change to the listener, need to hold the lock
+func (l *lRUStore) emit(t EventType, key string, value Value) {
+	if l.listener != nil {
+		l.listener(Event{Type: t, Key: key, Value: value})
+	}
+}
+
+// expireLazily removes key if it is still expired, so a read of an expired
+// entry reports EventExpire without waiting for the cleanup loop. need to hold the lock
+func (l *lRUStore) expireLazily(key string) {
+	elem, ok := l.items[key]
+	if !ok {
+		return
+	}
+	if expiresAt := l.expires[key]; expiresAt.IsZero() || !expiresAt.Before(time.Now()) {
+		return
+	}
+	l.removeElement(elem)
+	l.expirations++
+	l.emit(EventExpire, key, elem.Value.(*lruEntry).value)
+}
+
+// live returns the element for key unless it is missing or expired, need to hold the lock
+func (l *lRUStore) live(key string) (*list.Element, bool) {
+	elem, ok := l.items[key]
+	if !ok {
+		return nil, false
+	}
+	if expiresAt := l.expires[key]; !expiresAt.IsZero() && expiresAt.Before(time.Now()) {
+		return nil, false
+	}
+	return elem, true
+}
+
+func (l *lRUStore) GetVersion(key string) (Value, uint64, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	elem, ok := l.live(key)
+	if !ok {
+		return nil, 0, false
+	}
+	l.list.MoveToFront(elem)
+	entry := elem.Value.(*lruEntry)
+	return entry.value, entry.version, true
+}
+
+func (l *lRUStore) SetIfVersion(key string, value Value, expiration time.Duration, version uint64) (uint64, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	elem, ok := l.live(key)
+	var have uint64
+	if ok {
+		have = elem.Value.(*lruEntry).version
+	}
+	if err := checkVersion(version, have, ok); err != nil {
+		return have, err
+	}
+	if !ok {
+		// drop an expired leftover so the write counts as new
+		l.deleteLocked(key)
+	}
+	if err := l.setLocked(key, value, expiration); err != nil {
+		return have, err
+	}
+	newVersion := l.version
+	l.afterWrite()
+	return newVersion, nil
+}
+
+func (l *lRUStore) DeleteIfVersion(key string, version uint64) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	elem, ok := l.live(key)
+	if !ok {
+		return ErrNotFound
+	}
+	if err := checkVersion(version, elem.Value.(*lruEntry).version, true); err != nil {
+		return err
+	}
+	l.deleteLocked(key)
+	return nil
+}
+
+func (l *lRUStore) Rename(oldKey, newKey string, overwrite bool) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	elem, ok := l.live(oldKey)
+	if !ok {
+		return ErrNotFound
+	}
+	if oldKey == newKey {
+		return nil
+	}
+	target, exists := l.live(newKey)
+	if exists && !overwrite {
+		return ErrKeyExists
+	}
+
+	entry := elem.Value.(*lruEntry)
+	size := int64(entry.value.Len())
+	var targetSize int64
+	if exists {
+		targetSize = int64(target.Value.(*lruEntry).value.Len())
+	}
+	// check the new key's quota as if the entry had already left the old one
+	l.quotas.charge(oldKey, -size, -1)
+	if err := l.quotas.check(newKey, targetSize, size, exists); err != nil {
+		l.quotas.charge(oldKey, size, 1)
+		return err
+	}
+	if target, ok := l.items[newKey]; ok {
+		l.removeElement(target)
+	}
+
+	expiresAt, hasExpiry := l.expires[oldKey]
+	delete(l.items, oldKey)
+	delete(l.expires, oldKey)
+	entry.key = newKey
+	l.version++
+	entry.version = l.version
+	l.items[newKey] = elem
+	if hasExpiry {
+		l.expires[newKey] = expiresAt
+	}
+	l.quotas.charge(newKey, size, 1)
+
+	l.emit(EventDelete, oldKey, entry.value)
+	if exists {
+		l.emit(EventUpdate, newKey, entry.value)
+	} else {
+		l.emit(EventSet, newKey, entry.value)
+	}
+	return nil
+}
+
+func (l *lRUStore) Update(fn func(tx Tx) error) (err error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	tx := &lruTx{l: l}
+	listener := l.listener
+	l.listener = func(e Event) { tx.events = append(tx.events, e) }
+	defer func() {
+		if r := recover(); r != nil {
+			tx.rollback()
+			l.listener = listener
+			panic(r)
+		}
+		if err != nil {
+			tx.rollback()
+		}
+		l.listener = listener
+		if err == nil {
+			for _, e := range tx.events {
+				l.emit(e.Type, e.Key, e.Value)
 			}
-			entry := elem.Value.(*lruEntry)
-			l.list.Remove(elem)
-			delete(l.items, entry.key)
-			l.usedBytes -= int64(entry.value.Len())
-			delete(l.expires, entry.key)
+			l.afterWrite()
+		}
+	}()
+	return fn(tx)
+}
+
+// lruTx runs under the store lock held by Update
+
+type lruTx struct {
+	l      *lRUStore
+	undo   []txUndo
+	events []Event
+}
+
+func (t *lruTx) Get(key string) (Value, time.Time, bool) {
+	elem, ok := t.l.items[key]
+	if !ok {
+		return nil, time.Time{}, false
+	}
+	expiresAt := t.l.expires[key]
+	if !expiresAt.IsZero() && expiresAt.Before(time.Now()) {
+		return nil, time.Time{}, false
+	}
+	return elem.Value.(*lruEntry).value, expiresAt, true
+}
+
+func (t *lruTx) Set(key string, value Value, expiration time.Duration) error {
+	if value == nil {
+		t.Delete(key)
+		return nil
+	}
+	t.save(key)
+	return t.l.setLocked(key, value, expiration)
+}
+
+func (t *lruTx) Delete(key string) bool {
+	t.save(key)
+	return t.l.deleteLocked(key)
+}
+
+func (t *lruTx) save(key string) {
+	u := txUndo{key: key}
+	if elem, ok := t.l.items[key]; ok {
+		entry := elem.Value.(*lruEntry)
+		u.value, u.version, u.expiresAt, u.existed = entry.value, entry.version, t.l.expires[key], true
+	}
+	t.undo = append(t.undo, u)
+}
+
+// rollback restores every saved key, newest change first
+func (t *lruTx) rollback() {
+	for i := len(t.undo) - 1; i >= 0; i-- {
+		u := t.undo[i]
+		if !u.existed {
+			t.l.deleteLocked(u.key)
+			continue
+		}
+		t.l.setLocked(u.key, u.value, 0)
+		t.l.items[u.key].Value.(*lruEntry).version = u.version
+		if u.expiresAt.IsZero() {
+			delete(t.l.expires, u.key)
 		} else {
-			break
+			t.l.expires[u.key] = u.expiresAt
 		}
 	}
 }
 
+func (l *lRUStore) SetQuota(prefix string, q Quota) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.quotas.set(prefix, q, l.eachSize)
+}
+
+func (l *lRUStore) RemoveQuota(prefix string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.quotas.remove(prefix, l.eachSize)
+}
+
+func (l *lRUStore) QuotaUsage() map[string]QuotaUsage {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.quotas.snapshot()
+}
+
+func (l *lRUStore) eachSize(fn func(key string, size int64)) {
+	for key, elem := range l.items {
+		fn(key, int64(elem.Value.(*lruEntry).value.Len()))
+	}
+}
+
+func (l *lRUStore) Counters() map[string]int64 {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	var tickers int64
+	if l.cleanupTicker != nil && !l.closed {
+		tickers = 1
+	}
+	return map[string]int64{
+		"evictions":   l.evictions,
+		"expirations": l.expirations,
+		"pinned":      int64(len(l.pins)),
+
+		"cleanup_runs":          l.cleanupRuns,
+		"cleanup_expired":       l.cleanupExpired,
+		"cleanup_examined":      l.cleanupExamined,
+		"cleanup_time_us":       l.cleanupTime.Microseconds(),
+		"cleanup_last_examined": int64(l.lastCleanup.examined),
+		"cleanup_last_expired":  int64(l.lastCleanup.removed),
+		"cleanup_last_us":       l.lastCleanup.took.Microseconds(),
+		"cleanup_interval_ms":   l.cleanupDelay.Milliseconds(),
+
+		"eviction_batches": l.evictionBatches,
+		"evictor_runs":     l.evictorRuns,
+
+		"store_goroutines": atomic.LoadInt64(&l.goroutines),
+		"store_tickers":    tickers,
+	}
+}
+
+// CleanupStore removes expired entries every cleanup interval until Close, the
+// constructor runs it when the interval is positive
 func (l *lRUStore) CleanupStore() {
+	if l.cleanupTicker == nil {
+		return
+	}
 	for {
 		select {
 		case <-l.closeCh:
 			return
 		case <-l.cleanupTicker.C:
 			l.mu.Lock()
-			l.evict()
+			l.cleanupPass()
 			l.mu.Unlock()
 		}
 	}
 }
+
+// cleanupPass is one run of the cleanup loop, need to hold the lock
+func (l *lRUStore) cleanupPass() {
+	start := time.Now()
+	var pass cleanupStats
+	more := false
+	if !l.paused {
+		pass.examined, pass.removed, more = l.sweepExpired(start)
+	}
+	l.evictSize(l.maxBytes)
+	pass.took = time.Since(start)
+
+	l.cleanupRuns++
+	l.cleanupExpired += int64(pass.removed)
+	l.cleanupExamined += int64(pass.examined)
+	l.cleanupTime += pass.took
+	l.lastCleanup = pass
+	if l.adaptiveCleanup {
+		l.pace(pass, more)
+	}
+}
+
+// pace adapts the cleanup interval to how much the last pass found: it halves,
+// down to a sixteenth of the configured interval, while a pass hits the batch
+// limit or finds more than a quarter of the TTL'd entries expired, and doubles
+// back up to the configured interval once a pass finds nothing. need to hold the lock
+func (l *lRUStore) pace(pass cleanupStats, more bool) {
+	delay := l.cleanupDelay
+	switch {
+	case more || pass.removed*4 > pass.examined:
+		delay /= 2
+		if min := l.cleanupInterval / 16; delay < min {
+			delay = min
+		}
+	case pass.removed == 0:
+		delay *= 2
+		if delay > l.cleanupInterval {
+			delay = l.cleanupInterval
+		}
+	}
+	if delay != l.cleanupDelay && delay > 0 {
+		l.cleanupDelay = delay
+		l.cleanupTicker.Reset(delay)
+	}
+}
@@ -0,0 +1,196 @@
+package store
+
+import (
+	"sync"
+	"time"
+)
+
+var _ Store = (*MockStore)(nil)
+
+// MockCall is one recorded call on a MockStore
+type MockCall struct {
+	Method string
+	Args   []interface{}
+}
+
+// MockStore is a programmable Store for dependency injection in tests. Every call
+// is recorded; a method whose Func field is nil returns zero values.
+type MockStore struct {
+	mu    sync.Mutex
+	calls []MockCall
+
+	GetFunc               func(key string) (Value, bool)
+	PeekFunc              func(key string) (Value, time.Time, bool)
+	SetFunc               func(key string, value Value) error
+	SetWithExpirationFunc func(key string, value Value, expiration time.Duration) error
+	DeleteFunc            func(key string) bool
+	HasFunc               func(key string) bool
+	CountPrefixFunc       func(prefix string) int
+	ExpireFunc            func(key string, expiration time.Duration) bool
+	ClearFunc             func()
+	LenFunc               func() int
+	UsedBytesFunc         func() int64
+	MaxBytesFunc          func() int64
+	SetMaxBytesFunc       func(maxBytes int64)
+	TrimFunc              func(bytes int64) int64
+	RangeFunc             func(fn func(key string, value Value, expiresAt time.Time) bool)
+	CloseFunc             func()
+}
+
+func (m *MockStore) record(method string, args ...interface{}) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.calls = append(m.calls, MockCall{Method: method, Args: args})
+}
+
+// Calls returns the recorded calls in order
+func (m *MockStore) Calls() []MockCall {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]MockCall(nil), m.calls...)
+}
+
+// CallCount returns how many times method was called
+func (m *MockStore) CallCount(method string) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	n := 0
+	for _, c := range m.calls {
+		if c.Method == method {
+			n++
+		}
+	}
+	return n
+}
+
+// ResetCalls forgets the recorded calls
+func (m *MockStore) ResetCalls() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.calls = nil
+}
+
+func (m *MockStore) Get(key string) (Value, bool) {
+	m.record("Get", key)
+	if m.GetFunc != nil {
+		return m.GetFunc(key)
+	}
+	return nil, false
+}
+
+func (m *MockStore) Peek(key string) (Value, time.Time, bool) {
+	m.record("Peek", key)
+	if m.PeekFunc != nil {
+		return m.PeekFunc(key)
+	}
+	return nil, time.Time{}, false
+}
+
+func (m *MockStore) Set(key string, value Value) error {
+	m.record("Set", key, value)
+	if m.SetFunc != nil {
+		return m.SetFunc(key, value)
+	}
+	return nil
+}
+
+func (m *MockStore) SetWithExpiration(key string, value Value, expiration time.Duration) error {
+	m.record("SetWithExpiration", key, value, expiration)
+	if m.SetWithExpirationFunc != nil {
+		return m.SetWithExpirationFunc(key, value, expiration)
+	}
+	return nil
+}
+
+func (m *MockStore) Delete(key string) bool {
+	m.record("Delete", key)
+	if m.DeleteFunc != nil {
+		return m.DeleteFunc(key)
+	}
+	return false
+}
+
+func (m *MockStore) Has(key string) bool {
+	m.record("Has", key)
+	if m.HasFunc != nil {
+		return m.HasFunc(key)
+	}
+	return false
+}
+
+func (m *MockStore) CountPrefix(prefix string) int {
+	m.record("CountPrefix", prefix)
+	if m.CountPrefixFunc != nil {
+		return m.CountPrefixFunc(prefix)
+	}
+	return 0
+}
+
+func (m *MockStore) Expire(key string, expiration time.Duration) bool {
+	m.record("Expire", key, expiration)
+	if m.ExpireFunc != nil {
+		return m.ExpireFunc(key, expiration)
+	}
+	return false
+}
+
+func (m *MockStore) Clear() {
+	m.record("Clear")
+	if m.ClearFunc != nil {
+		m.ClearFunc()
+	}
+}
+
+func (m *MockStore) Len() int {
+	m.record("Len")
+	if m.LenFunc != nil {
+		return m.LenFunc()
+	}
+	return 0
+}
+
+func (m *MockStore) UsedBytes() int64 {
+	m.record("UsedBytes")
+	if m.UsedBytesFunc != nil {
+		return m.UsedBytesFunc()
+	}
+	return 0
+}
+
+func (m *MockStore) MaxBytes() int64 {
+	m.record("MaxBytes")
+	if m.MaxBytesFunc != nil {
+		return m.MaxBytesFunc()
+	}
+	return 0
+}
+
+func (m *MockStore) SetMaxBytes(maxBytes int64) {
+	m.record("SetMaxBytes", maxBytes)
+	if m.SetMaxBytesFunc != nil {
+		m.SetMaxBytesFunc(maxBytes)
+	}
+}
+
+func (m *MockStore) Trim(bytes int64) int64 {
+	m.record("Trim", bytes)
+	if m.TrimFunc != nil {
+		return m.TrimFunc(bytes)
+	}
+	return 0
+}
+
+func (m *MockStore) Range(fn func(key string, value Value, expiresAt time.Time) bool) {
+	m.record("Range")
+	if m.RangeFunc != nil {
+		m.RangeFunc(fn)
+	}
+}
+
+func (m *MockStore) Close() {
+	m.record("Close")
+	if m.CloseFunc != nil {
+		m.CloseFunc()
+	}
+}
@@ -0,0 +1,10 @@
+package store
+
+// Pauser is implemented by stores whose eviction and expiry cleanup can be
+// suspended, e.g. during a bulk load. While paused nothing expires or gets
+// evicted until usage passes limit bytes, then entries are evicted back down
+// to limit. A limit of 0 means no cap. Resume catches up on the backlog.
+type Pauser interface {
+	Pause(limit int64)
+	Resume()
+}
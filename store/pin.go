@@ -0,0 +1,27 @@
+package store
+
+// Pinner is implemented by stores that can protect keys from capacity eviction
+// and Trim. Pins are counted, a key stays protected until every Pin has been
+// matched by an Unpin. Pinned entries still expire, and the store may stay over
+// its byte budget while only pinned entries are left to evict.
+type Pinner interface {
+	Pin(key string)
+	Unpin(key string)
+}
+
+type pinSet map[string]int
+
+func (p *pinSet) pin(key string) {
+	if *p == nil {
+		*p = make(pinSet)
+	}
+	(*p)[key]++
+}
+
+func (p pinSet) unpin(key string) {
+	if p[key] <= 1 {
+		delete(p, key)
+	} else {
+		p[key]--
+	}
+}
@@ -0,0 +1,116 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var ErrQuotaExceeded = errors.New("store: quota exceeded")
+
+// Quota caps the entries whose keys start with a prefix, zero fields mean no limit
+type Quota struct {
+	MaxBytes   int64
+	MaxEntries int
+}
+
+type QuotaUsage struct {
+	Quota
+	Bytes    int64
+	Entries  int
+	Rejected int64 // Sets refused because they would exceed the quota
+}
+
+// QuotaStore is implemented by stores that enforce per-prefix quotas
+type QuotaStore interface {
+	// SetQuota installs or replaces the quota for keys starting with prefix
+	SetQuota(prefix string, q Quota)
+	RemoveQuota(prefix string)
+	QuotaUsage() map[string]QuotaUsage
+}
+
+// quotaTracker does the per-prefix accounting for a store, the store's lock guards it.
+// A key is charged to the longest prefix with a quota.
+
+type quotaTracker struct {
+	usage map[string]*QuotaUsage
+}
+
+func (q *quotaTracker) match(key string) *QuotaUsage {
+	var best *QuotaUsage
+	bestLen := -1
+	for prefix, u := range q.usage {
+		if len(prefix) > bestLen && strings.HasPrefix(key, prefix) {
+			best, bestLen = u, len(prefix)
+		}
+	}
+	return best
+}
+
+// check reports whether replacing an entry of oldSize (exists=false for a new key)
+// with one of newSize keeps key's prefix within its quota
+func (q *quotaTracker) check(key string, oldSize, newSize int64, exists bool) error {
+	u := q.match(key)
+	if u == nil {
+		return nil
+	}
+	bytes, entries := u.Bytes+newSize-oldSize, u.Entries
+	if !exists {
+		entries++
+	}
+	if (u.MaxBytes > 0 && bytes > u.MaxBytes) || (u.MaxEntries > 0 && entries > u.MaxEntries) {
+		u.Rejected++
+		return fmt.Errorf("%w: %q would hold %d bytes in %d entries, quota is %d bytes / %d entries",
+			ErrQuotaExceeded, key, bytes, entries, u.MaxBytes, u.MaxEntries)
+	}
+	return nil
+}
+
+// charge records a change of bytes and entries for key's prefix
+func (q *quotaTracker) charge(key string, bytes int64, entries int) {
+	if u := q.match(key); u != nil {
+		u.Bytes += bytes
+		u.Entries += entries
+	}
+}
+
+// set installs a quota, usage is recomputed from the given entries
+func (q *quotaTracker) set(prefix string, quota Quota, each func(fn func(key string, size int64))) {
+	if q.usage == nil {
+		q.usage = make(map[string]*QuotaUsage)
+	}
+	old := q.usage[prefix]
+	q.usage[prefix] = &QuotaUsage{Quota: quota}
+	if old != nil {
+		q.usage[prefix].Rejected = old.Rejected
+	}
+	q.recount(each)
+}
+
+func (q *quotaTracker) remove(prefix string, each func(fn func(key string, size int64))) {
+	delete(q.usage, prefix)
+	q.recount(each)
+}
+
+func (q *quotaTracker) recount(each func(fn func(key string, size int64))) {
+	for _, u := range q.usage {
+		u.Bytes, u.Entries = 0, 0
+	}
+	each(func(key string, size int64) {
+		q.charge(key, size, 1)
+	})
+}
+
+func (q *quotaTracker) reset() {
+	for _, u := range q.usage {
+		u.Bytes, u.Entries = 0, 0
+	}
+}
+
+func (q *quotaTracker) snapshot() map[string]QuotaUsage {
+	usage := make(map[string]QuotaUsage, len(q.usage))
+	for prefix, u := range q.usage {
+		usage[prefix] = *u
+	}
+	return usage
+}
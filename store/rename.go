@@ -0,0 +1,15 @@
+package store
+
+import "errors"
+
+var ErrKeyExists = errors.New("store: key already exists")
+
+// Renamer is implemented by stores that can move an entry to a new key in one
+// step, keeping its value, expiration and recency. The move publishes a delete
+// of the old key and a set (or update) of the new one, and gives the entry a
+// new version.
+type Renamer interface {
+	// Rename fails with ErrNotFound if oldKey is missing, and with ErrKeyExists
+	// if newKey is taken and overwrite is false
+	Rename(oldKey, newKey string, overwrite bool) error
+}
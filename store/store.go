@@ -1,17 +1,45 @@
 package store
 
-import "time"
+import (
+	"fmt"
+	"time"
+)
 
 type Store interface {
 	Get(key string) (Value, bool)
+	// Peek returns a live entry and its expiration without touching its recency
+	Peek(key string) (value Value, expiresAt time.Time, ok bool)
 	Set(key string, value Value) error
 	SetWithExpiration(key string, value Value, expiration time.Duration) error
 	Delete(key string) bool
+	// Has reports whether key holds a live entry without touching its recency
+	Has(key string) bool
+	// CountPrefix counts the live entries whose key starts with prefix
+	CountPrefix(prefix string) int
+	// Expire changes the expiration of an existing key, zero removes it
+	Expire(key string, expiration time.Duration) bool
 	Clear()
 	Len() int
+	UsedBytes() int64
+	MaxBytes() int64
+	// SetMaxBytes changes the byte budget, evicting right away if it shrank
+	SetMaxBytes(maxBytes int64)
+	// Trim evicts least recently used entries until at least bytes have been
+	// freed or the store is empty, returning the bytes freed
+	Trim(bytes int64) int64
+	// Range calls fn for every live entry, most recently used first, until fn
+	// returns false. A zero expiresAt means the entry doesn't expire. fn runs
+	// under the store lock and must not call back into the store.
+	Range(fn func(key string, value Value, expiresAt time.Time) bool)
 	Close()
 }
 
+// Reporter is implemented by stores that count their internal activity, e.g.
+// "evictions" and "expirations"
+type Reporter interface {
+	Counters() map[string]int64
+}
+
 type Value interface {
 	Len() int
 }
@@ -25,10 +53,35 @@ const (
 
 type Options struct {
 	MaxBytes        int64
-	CleanupInterval time.Duration
+	CleanupInterval time.Duration                 // How often expired entries are removed, 0 disables the cleanup loop
+	AdaptiveCleanup bool                          // Runs cleanup more often, down to CleanupInterval/16, while passes keep finding expired entries
 	OnEvicted       func(key string, value Value) // Callback when an item is evicted
+	EvictionBatch   int                           // Most entries evicted per lock hold, 0 uses DefaultEvictionBatch
+
+	// AsyncEviction moves size eviction off the write path: writes never evict,
+	// a background evictor wakes once usage passes HighWatermark and evicts down
+	// to LowWatermark. Both are fractions of MaxBytes, defaulting to 0.95 and
+	// 0.85. Usage may briefly exceed MaxBytes while the evictor catches up.
+	AsyncEviction bool
+	HighWatermark float64
+	LowWatermark  float64
+}
+
+// watermarks returns the high and low watermarks with defaults applied
+func (o Options) watermarks() (high, low float64) {
+	high, low = o.HighWatermark, o.LowWatermark
+	if high == 0 {
+		high = 0.95
+	}
+	if low == 0 {
+		low = 0.85
+	}
+	return high, low
 }
 
+// DefaultEvictionBatch bounds how long a single eviction pass holds the lock
+const DefaultEvictionBatch = 1024
+
 func DefaultOptions() Options {
 	return Options{
 		MaxBytes:        8 * 1024 * 1024, // 8MB
@@ -37,13 +90,35 @@ func DefaultOptions() Options {
 	}
 }
 
-func NewStore(cacheType CacheType, opts Options) Store {
+func (o Options) Validate() error {
+	if o.MaxBytes < 0 {
+		return fmt.Errorf("store: MaxBytes must not be negative, got %d", o.MaxBytes)
+	}
+	if o.CleanupInterval < 0 {
+		return fmt.Errorf("store: CleanupInterval must not be negative, got %v", o.CleanupInterval)
+	}
+	if o.EvictionBatch < 0 {
+		return fmt.Errorf("store: EvictionBatch must not be negative, got %d", o.EvictionBatch)
+	}
+	if high, low := o.watermarks(); high > 1 || low < 0 || low > high {
+		return fmt.Errorf("store: watermarks must satisfy 0 <= low <= high <= 1, got low %v high %v", low, high)
+	}
+	return nil
+}
+
+func NewStore(cacheType CacheType, opts Options) (Store, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
 	switch cacheType {
 	case LRU2:
-		return newLRU2Store(opts)
-	case LRU:
-		return newLRUStore(opts)
+		if s := newLRU2Store(opts); s != nil {
+			return s, nil
+		}
+		return nil, fmt.Errorf("store: cache type %q is not implemented", cacheType)
+	case LRU, "":
+		return newLRUStore(opts), nil
 	default:
-		return newLRUStore(opts)
+		return nil, fmt.Errorf("store: unknown cache type %q", cacheType)
 	}
 }
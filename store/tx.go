@@ -0,0 +1,29 @@
+package store
+
+import "time"
+
+// Tx reads and writes a store inside Transactor.Update. Reads see the
+// transaction's own writes and don't change recency.
+type Tx interface {
+	Get(key string) (value Value, expiresAt time.Time, ok bool)
+	// Set stores value, a zero expiration keeps the entry's current one
+	Set(key string, value Value, expiration time.Duration) error
+	Delete(key string) bool
+}
+
+// Transactor is implemented by stores that can apply several changes atomically.
+// Update runs fn under the store lock; if fn returns an error (or panics) every
+// change it made is undone and no events are published for it.
+type Transactor interface {
+	Update(fn func(tx Tx) error) error
+}
+
+// txUndo remembers the state of a key before a transaction first changed it
+
+type txUndo struct {
+	key       string
+	value     Value
+	version   uint64
+	expiresAt time.Time
+	existed   bool
+}
@@ -0,0 +1,280 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	_ StoreV2 = (*upgraded)(nil)
+	_ Store   = (*downgraded)(nil)
+)
+
+// StoreV2 is the context-aware form of Store, for implementations backed by
+// disk or network tiers: every call that touches data takes a context, honors
+// its cancellation and can fail. Reads and deletes of a missing or expired key
+// return ErrNotFound. Len, UsedBytes and MaxBytes report local bookkeeping and
+// can't fail.
+type StoreV2 interface {
+	Get(ctx context.Context, key string) (Value, error)
+	// Peek returns a live entry and its expiration without touching its recency
+	Peek(ctx context.Context, key string) (value Value, expiresAt time.Time, err error)
+	// Set stores value, a zero expiration means it doesn't expire
+	Set(ctx context.Context, key string, value Value, expiration time.Duration) error
+	Delete(ctx context.Context, key string) error
+	Has(ctx context.Context, key string) (bool, error)
+	CountPrefix(ctx context.Context, prefix string) (int, error)
+	// Expire changes the expiration of an existing key, zero removes it
+	Expire(ctx context.Context, key string, expiration time.Duration) error
+	Clear(ctx context.Context) error
+	Len() int
+	UsedBytes() int64
+	MaxBytes() int64
+	SetMaxBytes(ctx context.Context, maxBytes int64) error
+	// Trim evicts least recently used entries until at least bytes have been
+	// freed or the store is empty, returning the bytes freed
+	Trim(ctx context.Context, bytes int64) (int64, error)
+	// Range calls fn for every live entry until fn returns false or ctx is done,
+	// see Store.Range
+	Range(ctx context.Context, fn func(key string, value Value, expiresAt time.Time) bool) error
+	Close() error
+}
+
+// Upgrade adapts a Store to StoreV2. Calls fail with ctx.Err() once ctx is done
+// but can't be interrupted once started, and misses become ErrNotFound.
+func Upgrade(s Store) StoreV2 {
+	if d, ok := s.(*downgraded); ok {
+		return d.s
+	}
+	return &upgraded{s: s}
+}
+
+type upgraded struct {
+	s Store
+}
+
+// Unwrap returns the adapted Store, e.g. to reach its optional interfaces
+func (u *upgraded) Unwrap() Store {
+	return u.s
+}
+
+func (u *upgraded) Get(ctx context.Context, key string) (Value, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	v, ok := u.s.Get(key)
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return v, nil
+}
+
+func (u *upgraded) Peek(ctx context.Context, key string) (Value, time.Time, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, time.Time{}, err
+	}
+	v, expiresAt, ok := u.s.Peek(key)
+	if !ok {
+		return nil, time.Time{}, ErrNotFound
+	}
+	return v, expiresAt, nil
+}
+
+func (u *upgraded) Set(ctx context.Context, key string, value Value, expiration time.Duration) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return u.s.SetWithExpiration(key, value, expiration)
+}
+
+func (u *upgraded) Delete(ctx context.Context, key string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if !u.s.Delete(key) {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (u *upgraded) Has(ctx context.Context, key string) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+	return u.s.Has(key), nil
+}
+
+func (u *upgraded) CountPrefix(ctx context.Context, prefix string) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	return u.s.CountPrefix(prefix), nil
+}
+
+func (u *upgraded) Expire(ctx context.Context, key string, expiration time.Duration) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if !u.s.Expire(key, expiration) {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (u *upgraded) Clear(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	u.s.Clear()
+	return nil
+}
+
+func (u *upgraded) Len() int         { return u.s.Len() }
+func (u *upgraded) UsedBytes() int64 { return u.s.UsedBytes() }
+func (u *upgraded) MaxBytes() int64  { return u.s.MaxBytes() }
+
+func (u *upgraded) SetMaxBytes(ctx context.Context, maxBytes int64) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	u.s.SetMaxBytes(maxBytes)
+	return nil
+}
+
+func (u *upgraded) Trim(ctx context.Context, bytes int64) (int64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	return u.s.Trim(bytes), nil
+}
+
+func (u *upgraded) Range(ctx context.Context, fn func(key string, value Value, expiresAt time.Time) bool) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	u.s.Range(func(key string, value Value, expiresAt time.Time) bool {
+		return ctx.Err() == nil && fn(key, value, expiresAt)
+	})
+	return ctx.Err()
+}
+
+func (u *upgraded) Close() error {
+	u.s.Close()
+	return nil
+}
+
+// Downgrade adapts a StoreV2 to Store so it can back a cache today, e.g. through
+// CacheOptions.Store. Calls run under context.Background(). A failed read is a
+// miss and a failed write reports its error where Store allows one; every
+// failure other than ErrNotFound goes to onError, which may be nil, and is
+// counted in the "store_errors" counter.
+func Downgrade(s StoreV2, onError func(op, key string, err error)) Store {
+	if u, ok := s.(*upgraded); ok && onError == nil {
+		return u.s
+	}
+	return &downgraded{s: s, onError: onError}
+}
+
+type downgraded struct {
+	s       StoreV2
+	onError func(op, key string, err error)
+	errors  int64
+}
+
+// Unwrap returns the adapted StoreV2
+func (d *downgraded) Unwrap() StoreV2 {
+	return d.s
+}
+
+// fail reports err unless it is nil or ErrNotFound, and returns whether it is nil
+func (d *downgraded) fail(op, key string, err error) bool {
+	if err == nil {
+		return true
+	}
+	if !errors.Is(err, ErrNotFound) {
+		atomic.AddInt64(&d.errors, 1)
+		if d.onError != nil {
+			d.onError(op, key, err)
+		}
+	}
+	return false
+}
+
+func (d *downgraded) Get(key string) (Value, bool) {
+	v, err := d.s.Get(context.Background(), key)
+	return v, d.fail("get", key, err)
+}
+
+func (d *downgraded) Peek(key string) (Value, time.Time, bool) {
+	v, expiresAt, err := d.s.Peek(context.Background(), key)
+	return v, expiresAt, d.fail("peek", key, err)
+}
+
+func (d *downgraded) Set(key string, value Value) error {
+	return d.SetWithExpiration(key, value, 0)
+}
+
+func (d *downgraded) SetWithExpiration(key string, value Value, expiration time.Duration) error {
+	err := d.s.Set(context.Background(), key, value, expiration)
+	d.fail("set", key, err)
+	return err
+}
+
+func (d *downgraded) Delete(key string) bool {
+	return d.fail("delete", key, d.s.Delete(context.Background(), key))
+}
+
+func (d *downgraded) Has(key string) bool {
+	ok, err := d.s.Has(context.Background(), key)
+	return d.fail("has", key, err) && ok
+}
+
+func (d *downgraded) CountPrefix(prefix string) int {
+	n, err := d.s.CountPrefix(context.Background(), prefix)
+	d.fail("count_prefix", prefix, err)
+	return n
+}
+
+func (d *downgraded) Expire(key string, expiration time.Duration) bool {
+	return d.fail("expire", key, d.s.Expire(context.Background(), key, expiration))
+}
+
+func (d *downgraded) Clear() {
+	d.fail("clear", "", d.s.Clear(context.Background()))
+}
+
+func (d *downgraded) Len() int         { return d.s.Len() }
+func (d *downgraded) UsedBytes() int64 { return d.s.UsedBytes() }
+func (d *downgraded) MaxBytes() int64  { return d.s.MaxBytes() }
+
+func (d *downgraded) SetMaxBytes(maxBytes int64) {
+	d.fail("set_max_bytes", "", d.s.SetMaxBytes(context.Background(), maxBytes))
+}
+
+func (d *downgraded) Trim(bytes int64) int64 {
+	n, err := d.s.Trim(context.Background(), bytes)
+	d.fail("trim", "", err)
+	return n
+}
+
+func (d *downgraded) Range(fn func(key string, value Value, expiresAt time.Time) bool) {
+	d.fail("range", "", d.s.Range(context.Background(), fn))
+}
+
+func (d *downgraded) Close() {
+	d.fail("close", "", d.s.Close())
+}
+
+// Counters reports store_errors plus the counters of the StoreV2 if it is a Reporter
+func (d *downgraded) Counters() map[string]int64 {
+	counters := map[string]int64{}
+	if r, ok := d.s.(Reporter); ok {
+		for k, v := range r.Counters() {
+			counters[k] = v
+		}
+	}
+	counters["store_errors"] = atomic.LoadInt64(&d.errors)
+	return counters
+}
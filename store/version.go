@@ -0,0 +1,35 @@
+package store
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	ErrVersionMismatch = errors.New("store: version mismatch")
+	ErrNotFound        = errors.New("store: key not found")
+)
+
+// Versioner is implemented by stores that give every write a version, increasing
+// across the whole store, so updaters can detect concurrent changes like
+// memcached CAS tokens.
+type Versioner interface {
+	// GetVersion returns a live entry and its version, counting as an access
+	GetVersion(key string) (Value, uint64, bool)
+	// SetIfVersion stores value only if the entry's version is still version,
+	// 0 meaning the key must not exist, and returns the new version
+	SetIfVersion(key string, value Value, expiration time.Duration, version uint64) (uint64, error)
+	// DeleteIfVersion removes key only if its version is still version
+	DeleteIfVersion(key string, version uint64) error
+}
+
+// checkVersion compares the version a caller expects with the current one
+func checkVersion(want uint64, have uint64, exists bool) error {
+	switch {
+	case !exists && want != 0:
+		return ErrNotFound
+	case exists && want != have:
+		return ErrVersionMismatch
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package LCache_go
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// tombstonePrune is how many tombstones accumulate before adding one sweeps
+// out the lapsed ones
+const tombstonePrune = 1024
+
+// tombstones remember recently deleted keys that must not be written again
+// until their window ends, keyed by store key
+
+type tombstones struct {
+	mu       sync.Mutex
+	until    map[string]time.Time
+	active   int64 // len(until), so writes skip the lock while there are none
+	rejected int64 // writes refused because of a tombstone
+}
+
+func (t *tombstones) add(key string, until time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.until == nil {
+		t.until = make(map[string]time.Time)
+	}
+	if len(t.until) >= tombstonePrune {
+		now := time.Now()
+		for k, u := range t.until {
+			if !now.Before(u) {
+				delete(t.until, k)
+			}
+		}
+	}
+	if until.After(t.until[key]) {
+		t.until[key] = until
+	}
+	atomic.StoreInt64(&t.active, int64(len(t.until)))
+}
+
+// blocks reports whether key is tombstoned, counting the rejected write
+func (t *tombstones) blocks(key string) bool {
+	if atomic.LoadInt64(&t.active) == 0 {
+		return false
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	until, ok := t.until[key]
+	if !ok {
+		return false
+	}
+	if !time.Now().Before(until) {
+		delete(t.until, key)
+		atomic.StoreInt64(&t.active, int64(len(t.until)))
+		return false
+	}
+	atomic.AddInt64(&t.rejected, 1)
+	return true
+}
+
+func (t *tombstones) stats(stats Stats) {
+	stats["tombstones"] = atomic.LoadInt64(&t.active)
+	stats["tombstone_rejections"] = atomic.LoadInt64(&t.rejected)
+}
+
+// checkTombstone fails writes to a tombstoned store key with ErrTombstoned
+func (c *Cache) checkTombstone(key, sk string) error {
+	if c.tombstones.blocks(sk) {
+		return fmt.Errorf("%w: %q", ErrTombstoned, key)
+	}
+	return nil
+}
+
+// DeleteWithDelay deletes key and tombstones it for d: until then every write
+// to key fails with ErrTombstoned and loaded values aren't cached, so a reader
+// still holding the old value can't put it back right after an invalidation.
+// The tombstone is placed even if key wasn't cached, in which case
+// ErrKeyNotFound is returned. A d of zero or less is a plain Remove.
+func (c *Cache) DeleteWithDelay(key string, d time.Duration) error {
+	if d > 0 {
+		if atomic.LoadInt32(&c.closed) == 1 {
+			return ErrCacheClosed
+		}
+		c.tombstones.add(c.storeKey(key), time.Now().Add(d))
+	}
+	return c.Remove(key)
+}
@@ -0,0 +1,86 @@
+package LCache_go
+
+import (
+	"sort"
+	"sync"
+)
+
+// heavy-hitter tracker using the space-saving algorithm: at most capacity keys
+// are counted, and a new key replaces the least counted one, inheriting its count
+// as an overestimate bound
+
+type KeyCount struct {
+	Key   string `json:"key"`
+	Count int64  `json:"count"`
+	Error int64  `json:"error"` // Count may overestimate the true count by up to Error
+}
+
+type topKeys struct {
+	mu       sync.Mutex
+	capacity int
+	counts   map[string]*KeyCount
+}
+
+func newTopKeys(capacity int) *topKeys {
+	return &topKeys{
+		capacity: capacity,
+		counts:   make(map[string]*KeyCount, capacity),
+	}
+}
+
+func (t *topKeys) record(key string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if kc, ok := t.counts[key]; ok {
+		kc.Count++
+		return
+	}
+	if len(t.counts) < t.capacity {
+		t.counts[key] = &KeyCount{Key: key, Count: 1}
+		return
+	}
+	var min *KeyCount
+	for _, kc := range t.counts {
+		if min == nil || kc.Count < min.Count {
+			min = kc
+		}
+	}
+	delete(t.counts, min.Key)
+	t.counts[key] = &KeyCount{Key: key, Count: min.Count + 1, Error: min.Count}
+}
+
+// top returns up to n keys, most accessed first
+func (t *topKeys) top(n int) []KeyCount {
+	t.mu.Lock()
+	keys := make([]KeyCount, 0, len(t.counts))
+	for _, kc := range t.counts {
+		keys = append(keys, *kc)
+	}
+	t.mu.Unlock()
+
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].Count != keys[j].Count {
+			return keys[i].Count > keys[j].Count
+		}
+		return keys[i].Key < keys[j].Key
+	})
+	if n >= 0 && n < len(keys) {
+		keys = keys[:n]
+	}
+	return keys
+}
+
+func (t *topKeys) reset() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.counts = make(map[string]*KeyCount, t.capacity)
+}
+
+// TopKeys returns up to n of the most read keys, or nil unless TrackTopKeys is set
+func (c *Cache) TopKeys(n int) []KeyCount {
+	if c.topKeys == nil {
+		return nil
+	}
+	return c.topKeys.top(n)
+}
@@ -0,0 +1,163 @@
+package LCache_go
+
+import (
+	"errors"
+	"fmt"
+	"lcache/store"
+	"sync/atomic"
+	"time"
+)
+
+// Txn reads and writes several keys atomically inside Cache.Tx. Other callers
+// see either none or all of its writes.
+
+type Txn struct {
+	c    *Cache
+	tx   store.Tx
+	done bool
+}
+
+// Get returns the current value of key, including writes made earlier in the
+// transaction. It doesn't count as a hit or miss.
+func (t *Txn) Get(key string) (ByteView, bool) {
+	if t.done {
+		return ByteView{}, false
+	}
+	sk := t.c.storeKey(key)
+	value, _, ok := t.tx.Get(sk)
+	if !ok {
+		return ByteView{}, false
+	}
+	bv, ok := value.(ByteView)
+	if !ok {
+		return ByteView{}, false
+	}
+	bv, err := t.c.decodeValue(sk, bv)
+	if errors.Is(err, ErrValueCorrupt) {
+		t.tx.Delete(sk)
+	}
+	return bv, err == nil
+}
+
+// Set stores value for DefaultTTL, or without expiration if it isn't set
+func (t *Txn) Set(key string, value ByteView) error {
+	return t.set(key, value, 0)
+}
+
+// SetWithTTL stores value for ttl, ttl must be positive
+func (t *Txn) SetWithTTL(key string, value ByteView, ttl time.Duration) error {
+	if ttl <= 0 {
+		return fmt.Errorf("%w: %v", ErrInvalidTTL, ttl)
+	}
+	return t.set(key, value, ttl)
+}
+
+func (t *Txn) set(key string, value ByteView, ttl time.Duration) error {
+	if t.done {
+		return ErrTxDone
+	}
+	if err := t.c.checkSize(key, value); err != nil {
+		return err
+	}
+	ttl = t.c.ttlFor(key, ttl)
+	if ttl > 0 && !t.c.ttlSupported {
+		return fmt.Errorf("%w: store can't expire %q", ErrNotSupported, key)
+	}
+	sk := t.c.storeKey(key)
+	if err := t.c.checkTombstone(key, sk); err != nil {
+		return err
+	}
+	value, err := t.c.encodeValue(sk, value)
+	if err != nil {
+		return err
+	}
+	if err := t.tx.Set(sk, value, ttl); err != nil {
+		return fmt.Errorf("lcache: set %q: %w", key, err)
+	}
+	return nil
+}
+
+// Delete removes key and reports whether it existed
+func (t *Txn) Delete(key string) bool {
+	if t.done {
+		return false
+	}
+	return t.tx.Delete(t.c.storeKey(key))
+}
+
+// Tx runs fn as one atomic read-modify-write over any number of keys. If fn
+// returns an error every write it made is undone and the error is returned.
+// fn runs under the store lock, so it should be short and must not call other
+// Cache methods. Stores that can't do transactions return ErrNotSupported.
+func (c *Cache) Tx(fn func(tx *Txn) error) error {
+	if !OpenedAndInitialized(c) {
+		return ErrCacheClosed
+	}
+	atomic.AddInt64(&c.inflight, 1)
+	defer atomic.AddInt64(&c.inflight, -1)
+	if atomic.LoadInt32(&c.closed) == 1 {
+		return ErrCacheClosed
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.store == nil {
+		return ErrCacheClosed
+	}
+	ts, ok := c.store.(store.Transactor)
+	if !ok {
+		return ErrNotSupported
+	}
+	return ts.Update(func(tx store.Tx) error {
+		txn := &Txn{c: c, tx: tx}
+		defer func() { txn.done = true }()
+		return fn(txn)
+	})
+}
+
+// GetSet stores newValue for DefaultTTL and returns the value it replaced, in
+// one atomic step. Failures, e.g. a closed cache or a value over MaxEntryBytes,
+// are logged and leave the entry untouched.
+func (c *Cache) GetSet(key string, newValue ByteView) (old ByteView, existed bool) {
+	err := c.Tx(func(tx *Txn) error {
+		old, existed = tx.Get(key)
+		return tx.Set(key, newValue)
+	})
+	if err != nil {
+		c.opLog(LevelWarn, "GetSet failed", OpSet, key, "error", err)
+		return ByteView{}, false
+	}
+	c.valueSizes.Record(int64(newValue.Len()))
+	return old, existed
+}
+
+// SetAll stores every entry for DefaultTTL (or its rule's TTL), or none of them: sizes are checked
+// up front and a quota rejection part way through rolls back the earlier writes.
+func (c *Cache) SetAll(entries map[string]ByteView) error {
+	var total int64
+	for key, value := range entries {
+		if err := c.checkSize(key, value); err != nil {
+			return err
+		}
+		total += int64(value.Len())
+	}
+	if maxBytes := atomic.LoadInt64(&c.maxBytes); maxBytes > 0 && total > maxBytes {
+		return fmt.Errorf("%w: %d entries totalling %d bytes exceed MaxBytes %d", ErrValueTooLarge, len(entries), total, maxBytes)
+	}
+
+	err := c.Tx(func(tx *Txn) error {
+		for key, value := range entries {
+			if err := tx.Set(key, value); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for _, value := range entries {
+		c.valueSizes.Record(int64(value.Len()))
+	}
+	return nil
+}
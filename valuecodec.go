@@ -0,0 +1,77 @@
+package LCache_go
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"sync/atomic"
+)
+
+var castagnoli = crc32.MakeTable(crc32.Castagnoli)
+
+// encodeValue applies EncodeValue, encryption and then the checksum on the way
+// into the store, key is the store key
+func (c *Cache) encodeValue(key string, value ByteView) (ByteView, error) {
+	b := value.b
+	var err error
+	if c.opts.EncodeValue != nil {
+		if b, err = c.opts.EncodeValue(key, b); err != nil {
+			atomic.AddInt64(&c.codecErrors, 1)
+			return ByteView{}, fmt.Errorf("%w: encode %q: %v", ErrValueCodec, key, err)
+		}
+	}
+	if c.cipher != nil {
+		if b, err = c.cipher.seal(key, b); err != nil {
+			atomic.AddInt64(&c.codecErrors, 1)
+			return ByteView{}, fmt.Errorf("%w: encrypt %q: %v", ErrValueCodec, key, err)
+		}
+	}
+	if c.opts.VerifyChecksums {
+		sum := make([]byte, len(b)+4)
+		copy(sum, b)
+		binary.LittleEndian.PutUint32(sum[len(b):], crc32.Checksum(b, castagnoli))
+		b = sum
+	}
+	return ByteView{b: b}, nil
+}
+
+// decodeValue undoes encodeValue on the way out of the store, key is the store key
+func (c *Cache) decodeValue(key string, value ByteView) (ByteView, error) {
+	b := value.b
+	var err error
+	if c.opts.VerifyChecksums {
+		n := len(b) - 4
+		if n < 0 || crc32.Checksum(b[:n], castagnoli) != binary.LittleEndian.Uint32(b[n:]) {
+			atomic.AddInt64(&c.corrupt, 1)
+			return ByteView{}, fmt.Errorf("%w: %q", ErrValueCorrupt, key)
+		}
+		b = b[:n]
+	}
+	if c.cipher != nil {
+		if b, err = c.cipher.open(key, b); err != nil {
+			atomic.AddInt64(&c.codecErrors, 1)
+			return ByteView{}, fmt.Errorf("%w: decrypt %q: %v", ErrValueCodec, key, err)
+		}
+	}
+	if c.opts.DecodeValue != nil {
+		if b, err = c.opts.DecodeValue(key, b); err != nil {
+			atomic.AddInt64(&c.codecErrors, 1)
+			return ByteView{}, fmt.Errorf("%w: decode %q: %v", ErrValueCodec, key, err)
+		}
+	}
+	return ByteView{b: b}, nil
+}
+
+// transformsValues reports whether values in the store differ from what callers see
+func (c *Cache) transformsValues() bool {
+	return c.opts.DecodeValue != nil || c.cipher != nil || c.opts.VerifyChecksums
+}
+
+// dropCorrupt deletes key from the store if err says its value is corrupt, need
+// to hold the read lock
+func (c *Cache) dropCorrupt(key string, err error) {
+	if errors.Is(err, ErrValueCorrupt) && c.store != nil {
+		c.store.Delete(key)
+	}
+}
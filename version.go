@@ -0,0 +1,165 @@
+package LCache_go
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"lcache/store"
+	"sync/atomic"
+	"time"
+)
+
+// versionStore returns the store as a store.Versioner, need to hold the read lock
+func (c *Cache) versionStore() (store.Versioner, error) {
+	if c.store == nil {
+		return nil, ErrCacheClosed
+	}
+	vs, ok := c.store.(store.Versioner)
+	if !ok {
+		return nil, ErrNotSupported
+	}
+	return vs, nil
+}
+
+// GetWithVersion reads key like Lookup and also returns its version, which
+// changes on every write to the key and can be passed to SetIfVersion or
+// DeleteIfVersion
+func (c *Cache) GetWithVersion(key string) (ByteView, uint64, error) {
+	defer c.latency.observe(OpGet, time.Now())
+	if !OpenedAndInitialized(c) {
+		c.recordMiss()
+		return ByteView{}, 0, ErrCacheClosed
+	}
+	if c.topKeys != nil {
+		c.topKeys.record(key)
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	vs, err := c.versionStore()
+	if err != nil {
+		c.recordMiss()
+		return ByteView{}, 0, err
+	}
+	sk := c.storeKey(key)
+	value, version, ok := vs.GetVersion(sk)
+	bv, isView := value.(ByteView)
+	if !ok || !isView {
+		c.recordMiss()
+		return ByteView{}, 0, ErrKeyNotFound
+	}
+	if bv, err = c.decodeValue(sk, bv); err != nil {
+		c.dropCorrupt(sk, err)
+		c.recordMiss()
+		return ByteView{}, 0, err
+	}
+	c.recordHit()
+	return bv, version, nil
+}
+
+// SetIfVersion stores value for DefaultTTL only if key is still at version, a
+// version of 0 meaning it must not exist yet. It returns the new version, or
+// ErrVersionMismatch / ErrKeyNotFound when someone else got there first.
+func (c *Cache) SetIfVersion(key string, value ByteView, version uint64) (uint64, error) {
+	return c.setIfVersion(context.Background(), key, value, 0, version)
+}
+
+// SetIfVersionWithTTL is SetIfVersion with an explicit ttl, ttl must be positive
+func (c *Cache) SetIfVersionWithTTL(key string, value ByteView, ttl time.Duration, version uint64) (uint64, error) {
+	if ttl <= 0 {
+		return 0, fmt.Errorf("%w: %v", ErrInvalidTTL, ttl)
+	}
+	return c.setIfVersion(context.Background(), key, value, ttl, version)
+}
+
+// SetIfVersionCtx is SetIfVersion honoring ctx cancellation and WithWriter, a
+// ttl of 0 means DefaultTTL
+func (c *Cache) SetIfVersionCtx(ctx context.Context, key string, value ByteView, ttl time.Duration, version uint64) (uint64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	if ttl < 0 {
+		return 0, fmt.Errorf("%w: %v", ErrInvalidTTL, ttl)
+	}
+	return c.setIfVersion(ctx, key, value, ttl, version)
+}
+
+func (c *Cache) setIfVersion(ctx context.Context, key string, value ByteView, ttl time.Duration, version uint64) (uint64, error) {
+	defer c.latency.observe(OpSet, time.Now())
+	if !OpenedAndInitialized(c) {
+		return 0, ErrCacheClosed
+	}
+	if err := c.checkSize(key, value); err != nil {
+		return 0, err
+	}
+	ttl = c.ttlFor(key, ttl)
+
+	atomic.AddInt64(&c.inflight, 1)
+	defer atomic.AddInt64(&c.inflight, -1)
+	if atomic.LoadInt32(&c.closed) == 1 {
+		return 0, ErrCacheClosed
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	vs, err := c.versionStore()
+	if err != nil {
+		return 0, err
+	}
+	if ttl > 0 && !c.ttlSupported {
+		return 0, fmt.Errorf("%w: store can't expire %q", ErrNotSupported, key)
+	}
+	sk := c.storeKey(key)
+	if err := c.checkTombstone(key, sk); err != nil {
+		return 0, err
+	}
+	if value, err = c.encodeValue(sk, value); err != nil {
+		return 0, err
+	}
+	value.writer = c.writerFrom(ctx)
+	drop, err := c.writeFault(key)
+	if err != nil {
+		return 0, err
+	}
+	if drop {
+		// the key stays where it was
+		return version, nil
+	}
+	newVersion, err := vs.SetIfVersion(sk, value, ttl, version)
+	if err != nil {
+		return newVersion, versionError(key, err)
+	}
+	c.valueSizes.Record(int64(value.Len()))
+	if c.shadow != nil {
+		c.shadow.set(sk, value.Len(), ttl)
+	}
+	return newVersion, nil
+}
+
+// DeleteIfVersion removes key only if it is still at version
+func (c *Cache) DeleteIfVersion(key string, version uint64) error {
+	defer c.latency.observe(OpDelete, time.Now())
+	if !OpenedAndInitialized(c) {
+		return ErrCacheClosed
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	vs, err := c.versionStore()
+	if err != nil {
+		return err
+	}
+	if err := vs.DeleteIfVersion(c.storeKey(key), version); err != nil {
+		return versionError(key, err)
+	}
+	return nil
+}
+
+func versionError(key string, err error) error {
+	switch {
+	case errors.Is(err, store.ErrNotFound):
+		return ErrKeyNotFound
+	case errors.Is(err, ErrVersionMismatch):
+		return ErrVersionMismatch
+	default:
+		return fmt.Errorf("lcache: set %q: %w", key, err)
+	}
+}
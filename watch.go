@@ -0,0 +1,57 @@
+package LCache_go
+
+import (
+	"context"
+	"strings"
+)
+
+// Watch delivers changes to keyOrPrefix until ctx is done or the cache closes,
+// then closes the channel. A trailing "*" watches every key with that prefix.
+// EventClear reaches every watcher. It is a filtered Subscribe, so a watcher
+// that doesn't keep up loses its oldest events.
+//
+// Keys go through KeyTransform like in Get and Set. Events of an exact watch
+// carry the key as given to Watch; prefix watches see transformed keys.
+func (c *Cache) Watch(ctx context.Context, keyOrPrefix string) <-chan KeyEvent {
+	if strings.HasSuffix(keyOrPrefix, "*") {
+		prefix := c.normalizeKey(strings.TrimSuffix(keyOrPrefix, "*"))
+		return c.subscribe(ctx, 0, func(ev KeyEvent) bool {
+			return ev.Type == EventClear || strings.HasPrefix(ev.Key, prefix)
+		})
+	}
+
+	key := c.storeKey(keyOrPrefix)
+	events := c.subscribe(ctx, 0, func(ev KeyEvent) bool {
+		return ev.Type == EventClear || ev.Key == key
+	})
+	if key == keyOrPrefix {
+		return events
+	}
+	// map the store key back to the caller's
+	out := make(chan KeyEvent, defaultEventBuffer)
+	done := c.res.acquire(resGoroutine, "watch")
+	go func() {
+		defer done()
+		defer close(out)
+		for ev := range events {
+			if ev.Type != EventClear {
+				ev.Key = keyOrPrefix
+			}
+			select {
+			case out <- ev:
+				continue
+			default:
+			}
+			// full: drop the oldest like the event bus does
+			select {
+			case <-out:
+			default:
+			}
+			select {
+			case out <- ev:
+			default:
+			}
+		}
+	}()
+	return out
+}
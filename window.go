@@ -0,0 +1,120 @@
+package LCache_go
+
+import (
+	"sync"
+	"time"
+)
+
+// ring buffer of hit/miss counters covering a fixed time window
+
+type windowBucket struct {
+	hits   int64
+	misses int64
+}
+
+type hitWindow struct {
+	mu      sync.Mutex
+	width   time.Duration // time covered by one bucket
+	buckets []windowBucket
+	last    int64 // bucket sequence number of the most recent write
+}
+
+func newHitWindow(window time.Duration, buckets int) *hitWindow {
+	return &hitWindow{
+		width:   window / time.Duration(buckets),
+		buckets: make([]windowBucket, buckets),
+	}
+}
+
+// advance moves the ring forward to now, zeroing buckets that fell out of the window
+func (w *hitWindow) advance(now time.Time) int {
+	seq := now.UnixNano() / int64(w.width)
+	n := int64(len(w.buckets))
+	if gap := seq - w.last; gap > 0 {
+		if gap > n {
+			gap = n
+		}
+		for i := int64(1); i <= gap; i++ {
+			w.buckets[(seq-gap+i)%n] = windowBucket{}
+		}
+		w.last = seq
+	}
+	return int(seq % n)
+}
+
+func (w *hitWindow) record(hit bool, now time.Time) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	b := &w.buckets[w.advance(now)]
+	if hit {
+		b.hits++
+	} else {
+		b.misses++
+	}
+}
+
+func (w *hitWindow) counts(now time.Time) (hits, misses int64) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.advance(now)
+	for _, b := range w.buckets {
+		hits += b.hits
+		misses += b.misses
+	}
+	return hits, misses
+}
+
+func (w *hitWindow) reset() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for i := range w.buckets {
+		w.buckets[i] = windowBucket{}
+	}
+}
+
+// windowed hit rate over the last minute, five minutes and hour
+
+type rollingStats struct {
+	windows map[string]*hitWindow
+}
+
+func newRollingStats() *rollingStats {
+	return &rollingStats{
+		windows: map[string]*hitWindow{
+			"1m": newHitWindow(time.Minute, 60),
+			"5m": newHitWindow(5*time.Minute, 60),
+			"1h": newHitWindow(time.Hour, 60),
+		},
+	}
+}
+
+func (r *rollingStats) record(hit bool) {
+	now := time.Now()
+	for _, w := range r.windows {
+		w.record(hit, now)
+	}
+}
+
+func (r *rollingStats) reset() {
+	for _, w := range r.windows {
+		w.reset()
+	}
+}
+
+// hitRates returns the hit rate of every window, keyed by window name
+func (r *rollingStats) hitRates() map[string]float64 {
+	now := time.Now()
+	rates := make(map[string]float64, len(r.windows))
+	for name, w := range r.windows {
+		hits, misses := w.counts(now)
+		if total := hits + misses; total > 0 {
+			rates[name] = float64(hits) / float64(total)
+		} else {
+			rates[name] = 0.0
+		}
+	}
+	return rates
+}
@@ -0,0 +1,31 @@
+package LCache_go
+
+import "context"
+
+// loaderWriter attributes values stored by a Loader, BatchLoader or fallback
+const loaderWriter = "loader"
+
+type writerKey struct{}
+
+// WithWriter labels the writes made through SetCtx with ctx as coming from
+// writer, e.g. a service or job name; with TrackWriters the label shows up as
+// EntryInfo.Writer
+func WithWriter(ctx context.Context, writer string) context.Context {
+	return context.WithValue(ctx, writerKey{}, writer)
+}
+
+func (c *Cache) loaderWriter() string {
+	if !c.opts.TrackWriters {
+		return ""
+	}
+	return loaderWriter
+}
+
+// writerFrom returns the label of ctx when writers are tracked
+func (c *Cache) writerFrom(ctx context.Context) string {
+	if !c.opts.TrackWriters {
+		return ""
+	}
+	writer, _ := ctx.Value(writerKey{}).(string)
+	return writer
+}